@@ -36,6 +36,7 @@ func (i *schemas) Set(value string) error {
 }
 
 var schema schemas
+var strict bool
 
 func main() {
 	parseAndValidateArgs()
@@ -44,6 +45,10 @@ func main() {
 	for _, schema := range schema {
 		contents, err := ioutil.ReadFile(schema)
 		checkErr(err)
+		if strict {
+			_, err = avro.ParseSchemaStrict(string(contents))
+			checkErr(err)
+		}
 		schemas = append(schemas, string(contents))
 	}
 
@@ -54,6 +59,7 @@ func main() {
 
 func parseAndValidateArgs() {
 	flag.Var(&schema, "schema", "Path to avsc schema file.")
+	flag.BoolVar(&strict, "strict", false, "Validate schemas against the specification before registering any of them.")
 	flag.Parse()
 
 	if len(schema) == 0 {
@@ -0,0 +1,249 @@
+package avro
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io/ioutil"
+	"sync"
+
+	"github.com/golang/snappy"
+	"github.com/klauspost/compress/zstd"
+)
+
+// Names of the codecs that may be used to compress blocks of object container files.
+// Spec: https://avro.apache.org/docs/current/spec.html#Required+Codecs
+const (
+	// NullCodec leaves blocks uncompressed.
+	NullCodec = "null"
+
+	// DeflateCodec compresses blocks using the deflate algorithm as specified in RFC 1951.
+	DeflateCodec = "deflate"
+
+	// SnappyCodec compresses blocks using Google's Snappy, each block followed by the CRC32 checksum of its
+	// uncompressed data.
+	SnappyCodec = "snappy"
+
+	// ZstandardCodec compresses blocks using Facebook's Zstandard.
+	ZstandardCodec = "zstandard"
+)
+
+// DefaultCompressionLevel tells a codec to use its own default compression level.
+const DefaultCompressionLevel = -1
+
+// Codec is an interface that compresses and decompresses blocks of object container files.
+// Implementations must be safe for concurrent use.
+type Codec interface {
+	// Name returns the name of this Codec as stored in the avro.codec metadata of a data file.
+	Name() string
+
+	// Compress returns the compressed form of the given block.
+	Compress(block []byte) ([]byte, error)
+
+	// Decompress returns the original form of the given compressed block.
+	Decompress(block []byte) ([]byte, error)
+}
+
+// leveledCodec is implemented by codecs that support configurable compression levels.
+type leveledCodec interface {
+	Codec
+
+	withLevel(level int) (Codec, error)
+}
+
+// CodecRegistry holds the codecs known to data file readers and writers by their names.
+type CodecRegistry struct {
+	codecs map[string]Codec
+	lock   sync.RWMutex
+}
+
+// NewCodecRegistry creates a new CodecRegistry that knows all the codecs shipped with this package.
+func NewCodecRegistry() *CodecRegistry {
+	registry := &CodecRegistry{codecs: make(map[string]Codec)}
+	registry.Register(nullCodec{})
+	registry.Register(&deflateCodec{level: flate.DefaultCompression})
+	registry.Register(snappyCodec{})
+	registry.Register(&zstandardCodec{level: zstd.SpeedDefault})
+
+	return registry
+}
+
+// Register adds a codec to this CodecRegistry replacing any codec previously registered under the same name.
+func (r *CodecRegistry) Register(codec Codec) {
+	r.lock.Lock()
+	r.codecs[codec.Name()] = codec
+	r.lock.Unlock()
+}
+
+// Get looks up a codec by its name. Returns an error if there is no such codec in this CodecRegistry.
+func (r *CodecRegistry) Get(name string) (Codec, error) {
+	r.lock.RLock()
+	codec, ok := r.codecs[name]
+	r.lock.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("Unsupported codec: %s", name)
+	}
+
+	return codec, nil
+}
+
+var defaultCodecRegistry = NewCodecRegistry()
+
+// RegisterCodec adds a codec to the global CodecRegistry used by data file readers and writers unless told otherwise.
+func RegisterCodec(codec Codec) {
+	defaultCodecRegistry.Register(codec)
+}
+
+// GetCodec looks up a codec by its name in the global CodecRegistry.
+func GetCodec(name string) (Codec, error) {
+	return defaultCodecRegistry.Get(name)
+}
+
+func withCompressionLevel(codec Codec, level int) (Codec, error) {
+	if lc, ok := codec.(leveledCodec); ok && level != DefaultCompressionLevel {
+		return lc.withLevel(level)
+	}
+
+	return codec, nil
+}
+
+type nullCodec struct{}
+
+func (nullCodec) Name() string {
+	return NullCodec
+}
+
+func (nullCodec) Compress(block []byte) ([]byte, error) {
+	return block, nil
+}
+
+func (nullCodec) Decompress(block []byte) ([]byte, error) {
+	return block, nil
+}
+
+type deflateCodec struct {
+	level int
+}
+
+// NewDeflateCodec creates a deflate Codec with the given compression level.
+// Accepts the same values as compress/flate.
+func NewDeflateCodec(level int) (Codec, error) {
+	if level < flate.HuffmanOnly || level > flate.BestCompression {
+		return nil, fmt.Errorf("Invalid compression level: %d", level)
+	}
+
+	return &deflateCodec{level: level}, nil
+}
+
+func (*deflateCodec) Name() string {
+	return DeflateCodec
+}
+
+func (c *deflateCodec) Compress(block []byte) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	w, err := flate.NewWriter(buf, c.level)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = w.Write(block); err != nil {
+		return nil, err
+	}
+	if err = w.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (*deflateCodec) Decompress(block []byte) ([]byte, error) {
+	r := flate.NewReader(bytes.NewReader(block))
+	defer r.Close()
+
+	return ioutil.ReadAll(r)
+}
+
+func (*deflateCodec) withLevel(level int) (Codec, error) {
+	return NewDeflateCodec(level)
+}
+
+type snappyCodec struct{}
+
+func (snappyCodec) Name() string {
+	return SnappyCodec
+}
+
+func (snappyCodec) Compress(block []byte) ([]byte, error) {
+	compressed := snappy.Encode(nil, block)
+	checksum := make([]byte, 4)
+	binary.BigEndian.PutUint32(checksum, crc32.ChecksumIEEE(block))
+
+	return append(compressed, checksum...), nil
+}
+
+func (snappyCodec) Decompress(block []byte) ([]byte, error) {
+	if len(block) < 4 {
+		return nil, fmt.Errorf("Snappy block too short: %d bytes", len(block))
+	}
+	data, err := snappy.Decode(nil, block[:len(block)-4])
+	if err != nil {
+		return nil, err
+	}
+	if binary.BigEndian.Uint32(block[len(block)-4:]) != crc32.ChecksumIEEE(data) {
+		return nil, fmt.Errorf("Snappy block checksum mismatch")
+	}
+
+	return data, nil
+}
+
+type zstandardCodec struct {
+	level zstd.EncoderLevel
+
+	encoderOnce sync.Once
+	encoder     *zstd.Encoder
+	encoderErr  error
+	decoderOnce sync.Once
+	decoder     *zstd.Decoder
+	decoderErr  error
+}
+
+// NewZstandardCodec creates a Zstandard Codec with the given compression level.
+// Levels follow the zstd command line tool, from 1 (fastest) to 22 (best compression).
+func NewZstandardCodec(level int) (Codec, error) {
+	if level < 1 || level > 22 {
+		return nil, fmt.Errorf("Invalid compression level: %d", level)
+	}
+
+	return &zstandardCodec{level: zstd.EncoderLevelFromZstd(level)}, nil
+}
+
+func (*zstandardCodec) Name() string {
+	return ZstandardCodec
+}
+
+func (c *zstandardCodec) Compress(block []byte) ([]byte, error) {
+	c.encoderOnce.Do(func() {
+		c.encoder, c.encoderErr = zstd.NewWriter(nil, zstd.WithEncoderLevel(c.level))
+	})
+	if c.encoderErr != nil {
+		return nil, c.encoderErr
+	}
+
+	return c.encoder.EncodeAll(block, nil), nil
+}
+
+func (c *zstandardCodec) Decompress(block []byte) ([]byte, error) {
+	c.decoderOnce.Do(func() {
+		c.decoder, c.decoderErr = zstd.NewReader(nil)
+	})
+	if c.decoderErr != nil {
+		return nil, c.decoderErr
+	}
+
+	return c.decoder.DecodeAll(block, nil)
+}
+
+func (*zstandardCodec) withLevel(level int) (Codec, error) {
+	return NewZstandardCodec(level)
+}
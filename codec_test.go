@@ -0,0 +1,107 @@
+package avro
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestCodecsRoundTrip(t *testing.T) {
+	data := []byte(strings.Repeat("Avro object container file block ", 100))
+	for _, name := range []string{NullCodec, DeflateCodec, SnappyCodec, ZstandardCodec} {
+		codec, err := GetCodec(name)
+		assert(t, err, nil)
+		assert(t, codec.Name(), name)
+
+		compressed, err := codec.Compress(data)
+		assert(t, err, nil)
+		decompressed, err := codec.Decompress(compressed)
+		assert(t, err, nil)
+		assert(t, decompressed, data)
+	}
+}
+
+func TestSnappyCodecChecksum(t *testing.T) {
+	codec, err := GetCodec(SnappyCodec)
+	assert(t, err, nil)
+
+	compressed, err := codec.Compress([]byte("some data"))
+	assert(t, err, nil)
+	compressed[len(compressed)-1]++
+	if _, err = codec.Decompress(compressed); err == nil {
+		t.Fatal("Expected a checksum error")
+	}
+}
+
+func TestCompressionLevels(t *testing.T) {
+	_, err := NewDeflateCodec(9)
+	assert(t, err, nil)
+	_, err = NewDeflateCodec(10)
+	if err == nil {
+		t.Fatal("Expected an error for invalid deflate level")
+	}
+	_, err = NewZstandardCodec(19)
+	assert(t, err, nil)
+	_, err = NewZstandardCodec(0)
+	if err == nil {
+		t.Fatal("Expected an error for invalid zstandard level")
+	}
+}
+
+// reverseCodec is a trivial custom codec used to test codec registration.
+type reverseCodec struct{}
+
+func (reverseCodec) Name() string {
+	return "reverse"
+}
+
+func (reverseCodec) Compress(block []byte) ([]byte, error) {
+	return reverse(block), nil
+}
+
+func (reverseCodec) Decompress(block []byte) ([]byte, error) {
+	return reverse(block), nil
+}
+
+func reverse(block []byte) []byte {
+	reversed := make([]byte, len(block))
+	for i := range block {
+		reversed[len(block)-1-i] = block[i]
+	}
+	return reversed
+}
+
+func TestCustomCodec(t *testing.T) {
+	registry := NewCodecRegistry()
+	registry.Register(reverseCodec{})
+
+	_, err := GetCodec("reverse")
+	if err == nil {
+		t.Fatal("Custom codec should not be in the global registry")
+	}
+	RegisterCodec(reverseCodec{})
+	defer func() {
+		delete(defaultCodecRegistry.codecs, "reverse")
+	}()
+
+	buf := &bytes.Buffer{}
+	dfw, err := NewDataFileWriter(buf, MustParseSchema(primitiveSchemaRaw), NewSpecificDatumWriter())
+	assert(t, err, nil)
+	assert(t, dfw.SetCodec("reverse"), nil)
+	for i := 0; i < 10; i++ {
+		assert(t, dfw.Write(&primitive{LongField: int64(i)}), nil)
+	}
+	assert(t, dfw.Close(), nil)
+	delete(defaultCodecRegistry.codecs, "reverse")
+
+	dfr, err := newDataFileReaderBytes(buf.Bytes(), NewSpecificDatumReader())
+	assert(t, err, nil)
+	dfr.SetCodecRegistry(registry)
+	for i := 0; i < 10; i++ {
+		var p primitive
+		ok, err := dfr.Next(&p)
+		assert(t, err, nil)
+		assert(t, ok, true)
+		assert(t, p.LongField, int64(i))
+	}
+}
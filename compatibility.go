@@ -0,0 +1,211 @@
+package avro
+
+import "fmt"
+
+// Checks whether data written with one schema can be read with another one according to the schema resolution rules,
+// without the need to ask a schema registry.
+
+// IncompatibilityReason tells why a part of a writer's schema cannot be read with a reader's schema.
+type IncompatibilityReason string
+
+const (
+	// TypeMismatch means the types of the schemas differ and the writer's type cannot be promoted to the reader's.
+	TypeMismatch IncompatibilityReason = "TYPE_MISMATCH"
+
+	// NameMismatch means named schemas have different names.
+	NameMismatch IncompatibilityReason = "NAME_MISMATCH"
+
+	// FixedSizeMismatch means fixed schemas have different sizes.
+	FixedSizeMismatch IncompatibilityReason = "FIXED_SIZE_MISMATCH"
+
+	// MissingEnumSymbol means a symbol of the writer's enum is missing in the reader's enum, which has no default.
+	MissingEnumSymbol IncompatibilityReason = "MISSING_ENUM_SYMBOL"
+
+	// MissingDefault means a field of the reader's record is missing in the writer's record and has no default value.
+	MissingDefault IncompatibilityReason = "MISSING_DEFAULT"
+
+	// MissingUnionBranch means no branch of the reader's union matches the writer's schema.
+	MissingUnionBranch IncompatibilityReason = "MISSING_UNION_BRANCH"
+)
+
+// Incompatibility describes a part of a writer's schema that cannot be read with a reader's schema.
+type Incompatibility struct {
+	Reason IncompatibilityReason
+
+	// Path locates the incompatible part of the reader's schema by the names of the nested fields separated by dots,
+	// "[]" denoting array items and map values, e.g. "address.lines[]". It is empty for the schemas themselves.
+	Path string
+
+	// Message describes the incompatibility.
+	Message string
+
+	// Reader and Writer are the checked schemas.
+	Reader Schema
+	Writer Schema
+}
+
+// String returns a description of this Incompatibility.
+func (i Incompatibility) String() string {
+	if i.Path == "" {
+		return fmt.Sprintf("%s: %s", i.Reason, i.Message)
+	}
+	return fmt.Sprintf("%s at %s: %s", i.Reason, i.Path, i.Message)
+}
+
+// CheckReaderWriterCompatibility returns all incompatibilities preventing data written with the writer's schema from
+// being read with the reader's schema, or nil if there are none.
+// Branches of a writer's union are checked no matter whether data is actually written with them.
+func CheckReaderWriterCompatibility(reader, writer Schema) []Incompatibility {
+	checker := &compatibilityChecker{reader: reader, writer: writer, records: make(map[[2]*RecordSchema]bool)}
+	checker.check(reader, writer, "")
+	return checker.incompatibilities
+}
+
+// CheckCompatibility returns all incompatibilities of a new schema with the previous schemas of the same data,
+// ordered from oldest to newest, according to the given level, or nil if there are none:
+// backward compatibility means the new schema can read data written with the previous schema, forward compatibility
+// means the previous schema can read data written with the new schema and full compatibility means both.
+// Transitive levels check against all previous schemas instead of only the latest one.
+// Returns an error if the level is unknown.
+func CheckCompatibility(schema Schema, previous []Schema, level CompatibilityLevel) ([]Incompatibility, error) {
+	var backward, forward, transitive bool
+	switch level {
+	case NoneCompatibilityLevel:
+		return nil, nil
+	case BackwardCompatibilityLevel:
+		backward = true
+	case BackwardTransitiveCompatibilityLevel:
+		backward, transitive = true, true
+	case ForwardCompatibilityLevel:
+		forward = true
+	case ForwardTransitiveCompatibilityLevel:
+		forward, transitive = true, true
+	case FullCompatibilityLevel:
+		backward, forward = true, true
+	case FullTransitiveCompatibilityLevel:
+		backward, forward, transitive = true, true, true
+	default:
+		return nil, fmt.Errorf("Unknown compatibility level: %s", level)
+	}
+
+	if !transitive && len(previous) > 1 {
+		previous = previous[len(previous)-1:]
+	}
+	var incompatibilities []Incompatibility
+	for _, old := range previous {
+		if backward {
+			incompatibilities = append(incompatibilities, CheckReaderWriterCompatibility(schema, old)...)
+		}
+		if forward {
+			incompatibilities = append(incompatibilities, CheckReaderWriterCompatibility(old, schema)...)
+		}
+	}
+	return incompatibilities, nil
+}
+
+type compatibilityChecker struct {
+	reader, writer    Schema
+	incompatibilities []Incompatibility
+
+	// records already checked, these allow recursive schemas
+	records map[[2]*RecordSchema]bool
+}
+
+func (c *compatibilityChecker) add(reason IncompatibilityReason, path string, format string, args ...interface{}) {
+	c.incompatibilities = append(c.incompatibilities, Incompatibility{
+		Reason:  reason,
+		Path:    path,
+		Message: fmt.Sprintf(format, args...),
+		Reader:  c.reader,
+		Writer:  c.writer,
+	})
+}
+
+func (c *compatibilityChecker) check(reader, writer Schema, path string) {
+	reader, writer = actualSchema(reader), actualSchema(writer)
+	if writer.Type() == Union {
+		for _, branch := range writer.(*UnionSchema).Types {
+			c.check(reader, branch, path)
+		}
+		return
+	}
+	if reader.Type() == Union {
+		if index := unionBranch(writer, reader.(*UnionSchema)); index >= 0 {
+			c.check(reader.(*UnionSchema).Types[index], writer, path)
+		} else {
+			c.add(MissingUnionBranch, path, "No branch of the reader's union matches the writer's %s", writer.GetName())
+		}
+		return
+	}
+
+	if reader.Type() != writer.Type() {
+		if !promotable(writer.Type(), reader.Type()) {
+			c.add(TypeMismatch, path, "The writer's %s cannot be read as %s", writer.GetName(), reader.GetName())
+		}
+		return
+	}
+	if isNamed(reader) && !namesMatch(writer, reader) {
+		c.add(NameMismatch, path, "The writer's %s cannot be read as %s", writer.GetName(), reader.GetName())
+		return
+	}
+
+	switch r := reader.(type) {
+	case *FixedSchema:
+		if w := writer.(*FixedSchema); w.Size != r.Size {
+			c.add(FixedSizeMismatch, path, "The writer's fixed %s of size %d cannot be read with size %d", w.Name, w.Size, r.Size)
+		}
+	case *EnumSchema:
+		c.checkEnum(r, writer.(*EnumSchema), path)
+	case *ArraySchema:
+		c.check(r.Items, writer.(*ArraySchema).Items, path+"[]")
+	case *MapSchema:
+		c.check(r.Values, writer.(*MapSchema).Values, path+"[]")
+	case *RecordSchema:
+		c.checkRecord(r, writer.(*RecordSchema), path)
+	}
+}
+
+func (c *compatibilityChecker) checkEnum(reader, writer *EnumSchema, path string) {
+	if reader.Default != "" {
+		return
+	}
+
+	symbols := make(map[string]bool, len(reader.Symbols))
+	for _, symbol := range reader.Symbols {
+		symbols[symbol] = true
+	}
+
+	for _, symbol := range writer.Symbols {
+		if !symbols[symbol] {
+			c.add(MissingEnumSymbol, path, "The reader's enum %s does not have the symbol %s", reader.Name, symbol)
+		}
+	}
+}
+
+func (c *compatibilityChecker) checkRecord(reader, writer *RecordSchema, path string) {
+	key := [2]*RecordSchema{reader, writer}
+	if c.records[key] {
+		return
+	}
+	c.records[key] = true
+
+	writerFields := make([]*SchemaField, len(reader.Fields))
+	for i, target := range matchFields(writer, reader) {
+		if target >= 0 {
+			writerFields[target] = writer.Fields[i]
+		}
+	}
+
+	for i, field := range reader.Fields {
+		fieldPath := field.Name
+		if path != "" {
+			fieldPath = path + "." + field.Name
+		}
+
+		if writerField := writerFields[i]; writerField != nil {
+			c.check(field.Type, writerField.Type, fieldPath)
+		} else if !field.HasDefault && field.Default == nil {
+			c.add(MissingDefault, fieldPath, "The field %s is missing in the writer's record %s and has no default value", field.Name, writer.Name)
+		}
+	}
+}
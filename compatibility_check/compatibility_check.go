@@ -36,10 +36,18 @@ func (i *schemas) Set(value string) error {
 }
 
 var schema schemas
+var previous schemas
+var level string
 
 func main() {
 	parseflag()
 
+	// 指定了历史版本时在本地检测，不请求注册中心
+	if len(previous) > 0 {
+		checkLocal()
+		return
+	}
+
 	// 构造请求url
 	registryURL := os.Getenv(ENV_REGISTRY)
 	if registryURL == "" {
@@ -96,6 +104,34 @@ func main() {
 
 }
 
+func checkLocal() {
+	previousSchemas := make([]avro.Schema, 0)
+	for _, file := range previous {
+		parsedSchema, err := avro.ParseSchemaFile(file)
+		checkErr(err)
+		previousSchemas = append(previousSchemas, parsedSchema)
+	}
+
+	compatible := true
+	for _, file := range schema {
+		parsedSchema, err := avro.ParseSchemaFile(file)
+		checkErr(err)
+		incompatibilities, err := avro.CheckCompatibility(parsedSchema, previousSchemas, avro.CompatibilityLevel(level))
+		checkErr(err)
+		if len(incompatibilities) == 0 {
+			fmt.Println(file, "ok")
+			continue
+		}
+		compatible = false
+		for _, incompatibility := range incompatibilities {
+			fmt.Println(file, incompatibility)
+		}
+	}
+	if !compatible {
+		os.Exit(1)
+	}
+}
+
 func getRequest(method, urlpath string, body io.Reader) (*http.Request, error) {
 	request, err := http.NewRequest(method, urlpath, body)
 	if err != nil {
@@ -110,6 +146,8 @@ func getRequest(method, urlpath string, body io.Reader) (*http.Request, error) {
 
 func parseflag() {
 	flag.Var(&schema, "schema", "path to avsc schema file")
+	flag.Var(&previous, "previous", "path to avsc file of a previous schema version, oldest first; checks locally if given")
+	flag.StringVar(&level, "level", string(avro.BackwardCompatibilityLevel), "compatibility level of local checks")
 	flag.Parse()
 	if len(schema) == 0 {
 		fmt.Println("At least one --schema flag is required")
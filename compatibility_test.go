@@ -0,0 +1,94 @@
+package avro
+
+import "testing"
+
+func reasons(incompatibilities []Incompatibility) []string {
+	var result []string
+	for _, incompatibility := range incompatibilities {
+		result = append(result, string(incompatibility.Reason)+" "+incompatibility.Path)
+	}
+	return result
+}
+
+func TestCheckReaderWriterCompatibility(t *testing.T) {
+	writer := MustParseSchema(`{"type": "record", "name": "User", "fields": [
+		{"name": "id", "type": "int"},
+		{"name": "name", "type": "string"},
+		{"name": "status", "type": {"type": "enum", "name": "Status", "symbols": ["ACTIVE", "BANNED", "DELETED"]}},
+		{"name": "address", "type": {"type": "record", "name": "Address", "fields": [
+			{"name": "lines", "type": {"type": "array", "items": "string"}},
+			{"name": "zip", "type": {"type": "fixed", "name": "Zip", "size": 5}}
+		]}},
+		{"name": "scores", "type": {"type": "map", "values": ["null", "double"]}},
+		{"name": "next", "type": ["null", "User"]}
+	]}`)
+	reader := MustParseSchema(`{"type": "record", "name": "User", "fields": [
+		{"name": "id", "type": "long"},
+		{"name": "name", "type": "bytes"},
+		{"name": "email", "type": "string"},
+		{"name": "phone", "type": ["null", "string"]},
+		{"name": "status", "type": {"type": "enum", "name": "Status", "symbols": ["ACTIVE"]}},
+		{"name": "address", "type": {"type": "record", "name": "Address", "fields": [
+			{"name": "lines", "type": {"type": "array", "items": "int"}},
+			{"name": "zip", "type": {"type": "fixed", "name": "Zip", "size": 9}},
+			{"name": "country", "type": "string", "default": "CN"}
+		]}},
+		{"name": "scores", "type": {"type": "map", "values": "double"}},
+		{"name": "next", "type": ["null", "User"]}
+	]}`)
+
+	assert(t, CheckReaderWriterCompatibility(writer, writer), []Incompatibility(nil))
+	incompatibilities := CheckReaderWriterCompatibility(reader, writer)
+	assert(t, reasons(incompatibilities), []string{
+		"MISSING_DEFAULT email",
+		"MISSING_DEFAULT phone",
+		"MISSING_ENUM_SYMBOL status",
+		"MISSING_ENUM_SYMBOL status",
+		"TYPE_MISMATCH address.lines[]",
+		"FIXED_SIZE_MISMATCH address.zip",
+		"TYPE_MISMATCH scores[]",
+	})
+	assert(t, incompatibilities[2].Reader, reader)
+	assert(t, incompatibilities[2].String(), "MISSING_ENUM_SYMBOL at status: The reader's enum Status does not have the symbol BANNED")
+
+	assert(t, reasons(CheckReaderWriterCompatibility(MustParseSchema(`["null", "string"]`), &IntSchema{})), []string{"MISSING_UNION_BRANCH "})
+	assert(t, reasons(CheckReaderWriterCompatibility(
+		MustParseSchema(`{"type": "fixed", "name": "A", "size": 1}`),
+		MustParseSchema(`{"type": "fixed", "name": "B", "size": 1}`))), []string{"NAME_MISMATCH "})
+}
+
+func TestCheckCompatibility(t *testing.T) {
+	v1 := MustParseSchema(`{"type": "record", "name": "Event", "fields": [
+		{"name": "id", "type": "long"}
+	]}`)
+	v2 := MustParseSchema(`{"type": "record", "name": "Event", "fields": [
+		{"name": "id", "type": "long"},
+		{"name": "kind", "type": "string", "default": "click"}
+	]}`)
+	v3 := MustParseSchema(`{"type": "record", "name": "Event", "fields": [
+		{"name": "id", "type": "long"},
+		{"name": "kind", "type": "string"}
+	]}`)
+
+	for _, c := range []struct {
+		level    CompatibilityLevel
+		previous []Schema
+		expected []string
+	}{
+		{BackwardCompatibilityLevel, []Schema{v1, v2}, nil},
+		{BackwardTransitiveCompatibilityLevel, []Schema{v1, v2}, []string{"MISSING_DEFAULT kind"}},
+		{ForwardTransitiveCompatibilityLevel, []Schema{v1, v2}, nil},
+		{FullCompatibilityLevel, []Schema{v2}, nil},
+		{FullCompatibilityLevel, []Schema{v1}, []string{"MISSING_DEFAULT kind"}},
+		{FullTransitiveCompatibilityLevel, []Schema{v1, v2}, []string{"MISSING_DEFAULT kind"}},
+		{NoneCompatibilityLevel, []Schema{v1, v2}, nil},
+	} {
+		incompatibilities, err := CheckCompatibility(v3, c.previous, c.level)
+		assert(t, err, nil)
+		assert(t, reasons(incompatibilities), c.expected)
+	}
+
+	if _, err := CheckCompatibility(v3, []Schema{v1}, CompatibilityLevel("SOME")); err == nil {
+		t.Fatal("Expected an error for an unknown compatibility level")
+	}
+}
@@ -1,11 +1,16 @@
 package avro
 
 import (
+	"bufio"
 	"bytes"
+	"crypto/rand"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"math"
+	"os"
+	"sort"
+	"strings"
 )
 
 // Support decoding the avro Object Container File format.
@@ -26,19 +31,52 @@ const (
 	syncSize       = 16
 	schemaKey      = "avro.schema"
 	codecKey       = "avro.codec"
+
+	// metadata keys with this prefix are reserved for Avro itself
+	reservedMetaPrefix = "avro."
+
+	// size of the read buffer used by DataFileReader
+	readBufferSize = 64 * 1024
 )
 
+// DefaultSyncInterval is the approximate size of uncompressed blocks written by DataFileWriter unless told otherwise.
+const DefaultSyncInterval = 64 * 1024
+
 var magic = []byte{'O', 'b', 'j', version}
 
 // DataFileReader is a reader for Avro Object Container Files.
+// It keeps only the block being decoded in memory, so files of any size may be read.
 // More here: https://avro.apache.org/docs/current/spec.html#Object+Container+Files
 type DataFileReader struct {
-	data         []byte
+	input        *fileInput
 	header       *objFileHeader
+	schema       Schema
 	block        *DataBlock
-	dec          Decoder
 	blockDecoder Decoder
 	datum        DatumReader
+	codecs       *CodecRegistry
+	codec        Codec
+
+	// resolution of the file's schema to the reader's schema, nil if reading with the file's schema
+	resolve  resolveFunc
+	resolved *bytes.Buffer
+
+	// position of the first block in the file
+	dataStart int64
+	// position of the current block in the file
+	blockStart int64
+	// set when reading a split, the end of its byte range
+	split    bool
+	splitEnd int64
+	// set in recovery mode
+	recovery RecoveryHandler
+
+	// used to seek to values, see SeekToRecord
+	index *BlockIndex
+	skip  resolveFunc
+
+	// buffer for compressed blocks, reused between blocks
+	compressed []byte
 }
 
 // The header for object container files
@@ -57,6 +95,7 @@ func readObjFileHeader(dec *BinaryDecoder) (*objFileHeader, error) {
 }
 
 // NewDataFileReader creates a new DataFileReader for a given file and using the given DatumReader to read the data from that file.
+// The whole file is loaded in memory, use NewDataFileReaderAt with an *os.File to read large files.
 // May return an error if the file contains invalid data or is just missing.
 func NewDataFileReader(filename string, datumReader DatumReader) (*DataFileReader, error) {
 	buf, err := ioutil.ReadFile(filename)
@@ -67,64 +106,232 @@ func NewDataFileReader(filename string, datumReader DatumReader) (*DataFileReade
 	return newDataFileReaderBytes(buf, datumReader)
 }
 
-// separated out mainly for testing currently, will be refactored later for io.Reader paradigm
+// NewDataFileStreamReader creates a new DataFileReader reading sequentially from the given io.Reader and using the given
+// DatumReader to read the data, e.g. from a pipe or an HTTP response body. The resulting DataFileReader cannot Seek
+// backwards.
+// May return an error if the header cannot be read or is invalid.
+func NewDataFileStreamReader(input io.Reader, datumReader DatumReader) (*DataFileReader, error) {
+	return newDataFileReader(newFileInput(input), datumReader)
+}
+
+// NewDataFileReaderAt creates a new DataFileReader reading from the given io.ReaderAt of the given size and using the
+// given DatumReader to read the data. The resulting DataFileReader supports seeking to any position.
+// May return an error if the header cannot be read or is invalid.
+func NewDataFileReaderAt(input io.ReaderAt, size int64, datumReader DatumReader) (*DataFileReader, error) {
+	return newDataFileReader(newFileInput(io.NewSectionReader(input, 0, size)), datumReader)
+}
+
+// separated out mainly for testing
 func newDataFileReaderBytes(buf []byte, datumReader DatumReader) (reader *DataFileReader, err error) {
-	if len(buf) < len(magic) || !bytes.Equal(magic, buf[0:4]) {
-		return nil, NotAvroFile
-	}
+	return NewDataFileReaderAt(bytes.NewReader(buf), int64(len(buf)), datumReader)
+}
 
-	dec := NewBinaryDecoder(buf)
-	blockDecoder := NewBinaryDecoder(nil)
+func newDataFileReader(input *fileInput, datumReader DatumReader) (reader *DataFileReader, err error) {
 	reader = &DataFileReader{
-		data:         buf,
-		dec:          dec,
-		blockDecoder: blockDecoder,
+		input:        input,
+		blockDecoder: NewBinaryDecoder(nil),
 		datum:        datumReader,
+		codecs:       defaultCodecRegistry,
 	}
 
-	if reader.header, err = readObjFileHeader(dec); err != nil {
+	if reader.header, err = input.readHeader(); err != nil {
 		return nil, err
 	}
 
-	schema, err := ParseSchema(string(reader.header.Meta[schemaKey]))
-	if err != nil {
+	if reader.schema, err = ParseSchema(string(reader.header.Meta[schemaKey])); err != nil {
 		return nil, err
 	}
-	reader.datum.SetSchema(schema)
+	reader.datum.SetSchema(reader.schema)
 	reader.block = &DataBlock{}
+	reader.dataStart = input.pos
+
+	return reader, nil
+}
+
+// Schema returns the schema this DataFileReader's file was written with.
+func (reader *DataFileReader) Schema() Schema {
+	return reader.schema
+}
+
+// GetMeta returns the value of the given metadata key of the file header or nil if there is no such key.
+func (reader *DataFileReader) GetMeta(key string) []byte {
+	return reader.header.Meta[key]
+}
 
-	if reader.hasNextBlock() {
-		if err := reader.NextBlock(); err != nil {
+// MetaKeys returns all metadata keys of the file header in sorted order, including reserved avro.* keys.
+func (reader *DataFileReader) MetaKeys() []string {
+	keys := make([]string, 0, len(reader.header.Meta))
+	for key := range reader.header.Meta {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// SetReaderSchema tells this DataFileReader to read values as the given schema instead of the schema the file was
+// written with, applying Avro schema resolution: fields missing in the file take their default values, fields unknown
+// to the reader's schema are skipped and numeric values are promoted.
+// The DatumReader is switched to the reader's schema. Must be called before reading any data.
+// Returns an error if data written with the file's schema cannot be read as the given schema.
+func (reader *DataFileReader) SetReaderSchema(schema Schema) error {
+	resolve, err := newResolveFunc(reader.schema, schema)
+	if err != nil {
+		return err
+	}
+
+	reader.datum.SetSchema(schema)
+	reader.resolve = resolve
+	reader.resolved = &bytes.Buffer{}
+	return nil
+}
+
+// RecoveryHandler is called by a DataFileReader in recovery mode for every corrupted block it skips, with the
+// position of the block in the file and the error reading it.
+type RecoveryHandler func(offset int64, err error)
+
+// SetRecoveryHandler puts this DataFileReader in recovery mode, salvaging values from damaged files, e.g. files
+// partially written before a crash. Instead of failing, Next reports corrupted blocks to the given handler and
+// resynchronizes by scanning for the sync marker of the file after the start of the bad block. If a value cannot be
+// decoded, the rest of its block is skipped. Streams cannot go back, so there the scan starts after the data already
+// read. Passing nil turns recovery mode off.
+func (reader *DataFileReader) SetRecoveryHandler(handler RecoveryHandler) {
+	reader.recovery = handler
+}
+
+// SetCodecRegistry sets the registry used to look up the codec this DataFileReader's blocks are compressed with.
+// Uses the global registry if not called, see RegisterCodec. Must be called before reading any data.
+func (reader *DataFileReader) SetCodecRegistry(codecs *CodecRegistry) {
+	reader.codecs = codecs
+	reader.codec = nil
+}
+
+// codecName returns the name of the codec this DataFileReader's blocks are compressed with.
+func (reader *DataFileReader) codecName() string {
+	if codec, ok := reader.header.Meta[codecKey]; ok && len(codec) > 0 {
+		return string(codec)
+	}
+
+	return NullCodec
+}
+
+func (reader *DataFileReader) getCodec() (Codec, error) {
+	if reader.codec == nil {
+		codec, err := reader.codecs.Get(reader.codecName())
+		if err != nil {
 			return nil, err
 		}
+		reader.codec = codec
 	}
 
-	return reader, nil
+	return reader.codec, nil
 }
 
-// Seek switches the reading position in this DataFileReader to a provided value.
-func (reader *DataFileReader) Seek(pos int64) {
-	reader.dec.Seek(pos)
+// Seek switches the reading position in this DataFileReader to a provided value, which should be the start of a block.
+// Any unread data of the current block is discarded.
+// May return an error if this DataFileReader reads from a stream and the position is behind the current one.
+func (reader *DataFileReader) Seek(pos int64) error {
+	if err := reader.input.seek(pos); err != nil {
+		return err
+	}
+
+	reader.block.BlockRemaining = 0
+	reader.block.BlockSize = 0
+	reader.block.NumEntries = 0
+	reader.blockDecoder.SetBlock(&DataBlock{})
+	return nil
+}
+
+// SyncTo moves this DataFileReader to the first block that starts after the given position by scanning for the
+// sync marker of this file, similar to Java's DataFileReader.sync. If there is no such block, the DataFileReader is
+// positioned at the end of the file and Next returns no more values.
+// May return an error if the position cannot be reached or reading fails.
+func (reader *DataFileReader) SyncTo(pos int64) error {
+	if err := reader.Seek(pos); err != nil {
+		return err
+	}
+
+	return reader.input.skipPast(reader.header.Sync)
+}
+
+// SetSplit restricts this DataFileReader to the blocks of the byte range [start, end) of its file, so a file may be
+// split into ranges processed by several readers independently, like input splits of Hadoop.
+// A block belongs to the range its preceding sync marker starts in: the reader is moved to the first block whose sync
+// marker starts at or after start, and Next returns no more values after the block whose sync marker starts before
+// end. Readers of adjacent ranges thus read every block exactly once.
+// May return an error if the range is invalid or its start cannot be reached.
+func (reader *DataFileReader) SetSplit(start, end int64) error {
+	if start < 0 || end < start {
+		return fmt.Errorf("Invalid split: [%d, %d)", start, end)
+	}
+
+	var err error
+	if start <= reader.dataStart-syncSize {
+		// the sync marker ending the header is within the range
+		err = reader.Seek(reader.dataStart)
+	} else {
+		err = reader.SyncTo(start)
+	}
+	if err != nil {
+		return err
+	}
+
+	reader.split = true
+	reader.splitEnd = end
+	return nil
 }
 
 func (reader *DataFileReader) hasNext() (bool, error) {
-	if reader.block.BlockRemaining == 0 {
+	// skip over empty blocks, e.g. the one DataFileWriter writes on Close
+	for reader.block.BlockRemaining == 0 {
 		if int64(reader.block.BlockSize) != reader.blockDecoder.Tell() {
-			return false, BlockNotFinished
+			if reader.recovery == nil {
+				return false, BlockNotFinished
+			}
+			reader.recovery(reader.blockStart, BlockNotFinished)
+			reader.blockDecoder.Seek(int64(reader.block.BlockSize))
 		}
-		if reader.hasNextBlock() {
-			if err := reader.NextBlock(); err != nil {
+		hasNextBlock, err := reader.hasNextBlock()
+		if err != nil || !hasNextBlock {
+			return false, err
+		}
+		if err = reader.NextBlock(); err != nil {
+			if err = reader.recover(err); err != nil {
 				return false, err
 			}
-		} else {
-			return false, nil
 		}
 	}
 	return true, nil
 }
 
-func (reader *DataFileReader) hasNextBlock() bool {
-	return int64(len(reader.data)) > reader.dec.Tell()
+// recover reports a block that failed to be read in recovery mode and moves to the next sync marker after its start.
+// Returns the error to fail with if not in recovery mode or recovery is not possible.
+func (reader *DataFileReader) recover(err error) error {
+	// neither a missing codec nor a failing underlying reader is a corruption of the block
+	if reader.recovery == nil || reader.codec == nil || reader.input.err != nil {
+		return err
+	}
+
+	reader.recovery(reader.blockStart, err)
+	start := reader.blockStart + 1
+	if start < reader.input.pos && reader.input.seek(start) != nil {
+		// streams continue where they are
+		start = reader.input.pos
+	}
+	if err = reader.Seek(start); err != nil {
+		return err
+	}
+	return reader.input.skipPast(reader.header.Sync)
+}
+
+func (reader *DataFileReader) hasNextBlock() (bool, error) {
+	// blocks of a split are those whose preceding sync marker starts within its range
+	if reader.split && reader.input.pos-syncSize >= reader.splitEnd {
+		return false, nil
+	}
+
+	atEOF, err := reader.input.atEOF()
+	return !atEOF, err
 }
 
 // Next reads the next value from file and fills the given value with data.
@@ -132,127 +339,613 @@ func (reader *DataFileReader) hasNextBlock() bool {
 // Second return value indicates whether there was an error while reading data.
 // Returns (false, nil) when no more data left to read.
 func (reader *DataFileReader) Next(v interface{}) (bool, error) {
-	hasNext, err := reader.hasNext()
-	if err != nil {
-		return false, err
-	}
+	for {
+		hasNext, err := reader.hasNext()
+		if err != nil || !hasNext {
+			return false, err
+		}
 
-	if hasNext {
-		err := reader.datum.Read(v, reader.blockDecoder)
-		if err != nil {
+		err = reader.readDatum(v, reader.blockDecoder, reader.resolved)
+		if err == nil {
+			reader.block.BlockRemaining--
+			return true, nil
+		}
+		if reader.recovery == nil {
 			return false, err
 		}
-		reader.block.BlockRemaining--
-		return true, nil
+
+		// nothing after a value that cannot be decoded can be trusted, skip the rest of the block
+		reader.recovery(reader.blockStart, err)
+		reader.block.BlockRemaining = 0
+		reader.blockDecoder.Seek(int64(reader.block.BlockSize))
 	}
+}
 
-	return false, nil
+// readDatum reads a single value from dec, resolving it to the reader's schema if needed using the given buffer.
+func (reader *DataFileReader) readDatum(v interface{}, dec Decoder, resolved *bytes.Buffer) error {
+	if reader.resolve == nil {
+		return reader.datum.Read(v, dec)
+	}
+
+	resolved.Reset()
+	if err := reader.resolve(dec, NewBinaryEncoder(resolved)); err != nil {
+		return err
+	}
+	return reader.datum.Read(v, NewBinaryDecoder(resolved.Bytes()))
 }
 
 // NextBlock tells this DataFileReader to skip current block and move to next one.
 // May return an error if the block is malformed or no more blocks left to read.
 func (reader *DataFileReader) NextBlock() error {
-	blockCount, err := reader.dec.ReadLong()
+	reader.blockStart = reader.input.pos
+	codec, err := reader.getCodec()
 	if err != nil {
 		return err
 	}
 
-	blockSize, err := reader.dec.ReadLong()
+	blockCount, compressed, err := reader.readRawBlock()
 	if err != nil {
 		return err
 	}
 
-	if blockSize > math.MaxInt32 || blockSize < 0 {
-		return fmt.Errorf("Block size invalid or too large: %d", blockSize)
-	}
-
 	block := reader.block
-	if block.Data == nil || int64(len(block.Data)) < blockSize {
-		block.Data = make([]byte, blockSize)
+	if block.Data, err = codec.Decompress(compressed); err != nil {
+		return err
 	}
 	block.BlockRemaining = blockCount
 	block.NumEntries = blockCount
-	block.BlockSize = int(blockSize)
-	err = reader.dec.ReadFixedWithBounds(block.Data, 0, int(block.BlockSize))
+	block.BlockSize = len(block.Data)
+	reader.blockDecoder.SetBlock(reader.block)
+
+	return nil
+}
+
+// RawBlock is a block of an object container file as stored in the file, see DataFileReader.NextRawBlock and
+// DataFileWriter.AppendRawBlock.
+type RawBlock struct {
+	// Count is the number of values in the block.
+	Count int64
+
+	// Data holds the encoded values of the block compressed with Codec.
+	Data []byte
+
+	// Codec is the name of the codec Data is compressed with.
+	Codec string
+
+	// Schema is the schema the values of the block are written with.
+	Schema Schema
+}
+
+// NextRawBlock fills the given RawBlock with the next block of the file without decompressing or decoding it, e.g. to
+// copy it to another file. Any unread data of the current block is discarded and empty blocks are skipped.
+// The data of the block is only valid until the next read from this DataFileReader.
+// Returns (false, nil) when no more blocks left to read.
+func (reader *DataFileReader) NextRawBlock(block *RawBlock) (bool, error) {
+	if err := reader.Seek(reader.input.pos); err != nil {
+		return false, err
+	}
+
+	for {
+		hasNextBlock, err := reader.hasNextBlock()
+		if err != nil || !hasNextBlock {
+			return false, err
+		}
+		reader.blockStart = reader.input.pos
+		count, data, err := reader.readRawBlock()
+		if err != nil {
+			return false, err
+		}
+		if count == 0 {
+			continue
+		}
+
+		block.Count = count
+		block.Data = data
+		block.Codec = reader.codecName()
+		block.Schema = reader.schema
+		return true, nil
+	}
+}
+
+// readRawBlock reads the next block as stored in the file, checking its sync marker.
+// The returned data is only valid until the next call.
+func (reader *DataFileReader) readRawBlock() (int64, []byte, error) {
+	blockCount, err := reader.input.readLong()
 	if err != nil {
-		return err
+		return 0, nil, err
+	}
+
+	blockSize, err := reader.input.readLong()
+	if err != nil {
+		return 0, nil, err
 	}
+
+	if blockSize > math.MaxInt32 || blockSize < 0 {
+		return 0, nil, fmt.Errorf("Block size invalid or too large: %d", blockSize)
+	}
+
+	if int64(cap(reader.compressed)) < blockSize {
+		reader.compressed = make([]byte, blockSize)
+	}
+	compressed := reader.compressed[:blockSize]
+	if err = reader.input.readFull(compressed); err != nil {
+		return 0, nil, err
+	}
+
 	syncBuffer := make([]byte, syncSize)
-	err = reader.dec.ReadFixed(syncBuffer)
+	if err = reader.input.readFull(syncBuffer); err != nil {
+		return 0, nil, err
+	}
+	if !bytes.Equal(syncBuffer, reader.header.Sync) {
+		return 0, nil, InvalidSync
+	}
+
+	return blockCount, compressed, nil
+}
+
+// fileInput reads the raw structure of an object container file from an underlying io.Reader keeping track of the
+// current position in the file.
+type fileInput struct {
+	source io.Reader
+	buf    *bufio.Reader
+	pos    int64
+
+	// when set, all bytes read are copied here
+	recorder *bytes.Buffer
+
+	// the last error of the underlying reader other than reaching its end
+	err error
+}
+
+func newFileInput(source io.Reader) *fileInput {
+	return &fileInput{
+		source: source,
+		buf:    bufio.NewReaderSize(source, readBufferSize),
+	}
+}
+
+// readHeader reads the header of an object container file at the current position.
+func (in *fileInput) readHeader() (*objFileHeader, error) {
+	in.recorder = &bytes.Buffer{}
+	defer func() {
+		in.recorder = nil
+	}()
+
+	fileMagic := make([]byte, len(magic))
+	if err := in.readFull(fileMagic); err != nil && err != EOF {
+		return nil, err
+	} else if err != nil || !bytes.Equal(magic, fileMagic) {
+		return nil, NotAvroFile
+	}
+
+	// walk through the metadata map to find out where the header ends
+	for {
+		count, err := in.readLong()
+		if err != nil {
+			return nil, err
+		}
+		if count == 0 {
+			break
+		}
+		if count < 0 {
+			count = -count
+			if _, err = in.readLong(); err != nil {
+				return nil, err
+			}
+		}
+		for i := int64(0); i < 2*count; i++ {
+			if err = in.skipBytes(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := in.readFull(make([]byte, syncSize)); err != nil {
+		return nil, err
+	}
+
+	return readObjFileHeader(NewBinaryDecoder(in.recorder.Bytes()))
+}
+
+// atEOF checks whether the end of file is reached, returning the error if the underlying reader fails.
+func (in *fileInput) atEOF() (bool, error) {
+	_, err := in.buf.Peek(1)
+	if err == io.EOF {
+		return true, nil
+	}
+	return false, in.check(err)
+}
+
+// check returns EOF if the given error of the underlying reader means its end, which may be reached in the middle of
+// a read, or else the error itself.
+func (in *fileInput) check(err error) error {
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return EOF
+	}
+	if err != nil {
+		in.err = err
+	}
+	return err
+}
+
+func (in *fileInput) ReadByte() (byte, error) {
+	b, err := in.buf.ReadByte()
+	if err != nil {
+		return 0, in.check(err)
+	}
+	in.pos++
+	if in.recorder != nil {
+		in.recorder.WriteByte(b)
+	}
+	return b, nil
+}
+
+func (in *fileInput) readFull(p []byte) error {
+	n, err := io.ReadFull(in.buf, p)
+	in.pos += int64(n)
+	if in.recorder != nil {
+		in.recorder.Write(p[:n])
+	}
+	return in.check(err)
+}
+
+// readLong reads a zig-zag encoded long value like BinaryDecoder.ReadLong.
+func (in *fileInput) readLong() (int64, error) {
+	var value uint64
+	for offset := 0; ; offset++ {
+		if offset == maxLongBufSize {
+			return 0, LongOverflow
+		}
+
+		b, err := in.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		value |= uint64(b&0x7F) << uint(7*offset)
+		if b&0x80 == 0 {
+			break
+		}
+	}
+	return int64((value >> 1) ^ -(value & 1)), nil
+}
+
+func (in *fileInput) skipBytes() error {
+	length, err := in.readLong()
 	if err != nil {
 		return err
 	}
-	if !bytes.Equal(syncBuffer, reader.header.Sync) {
-		return InvalidSync
+	if length < 0 {
+		return NegativeBytesLength
+	}
+	if in.recorder == nil {
+		return in.discard(length)
 	}
-	reader.blockDecoder.SetBlock(reader.block)
 
+	// read in chunks, so a corrupt length fails at the end of file instead of allocating it at once
+	chunk := make([]byte, readBufferSize)
+	for length > 0 {
+		n := int64(len(chunk))
+		if n > length {
+			n = length
+		}
+		if err = in.readFull(chunk[:n]); err != nil {
+			return err
+		}
+		length -= n
+	}
 	return nil
 }
 
+func (in *fileInput) discard(n int64) error {
+	for n > 0 {
+		chunk := n
+		if chunk > readBufferSize {
+			chunk = readBufferSize
+		}
+		discarded, err := in.buf.Discard(int(chunk))
+		in.pos += int64(discarded)
+		n -= int64(discarded)
+		if err != nil {
+			return in.check(err)
+		}
+	}
+	return nil
+}
+
+// skipPast moves to the position right after the next occurrence of the given marker, or to the end of file if
+// there is none.
+func (in *fileInput) skipPast(marker []byte) error {
+	for {
+		chunk, err := in.buf.Peek(readBufferSize)
+		if i := bytes.Index(chunk, marker); i >= 0 {
+			return in.discard(int64(i + len(marker)))
+		}
+		if err == io.EOF {
+			return in.discard(int64(len(chunk)))
+		}
+		if err != nil && err != bufio.ErrBufferFull {
+			return in.check(err)
+		}
+		// keep the tail as the marker may be split between chunks
+		if err = in.discard(int64(len(chunk) - len(marker) + 1)); err != nil {
+			return err
+		}
+	}
+}
+
+// seek moves to the given position in the file, streams may only be moved forward.
+func (in *fileInput) seek(pos int64) error {
+	if pos == in.pos {
+		return nil
+	}
+
+	if seeker, ok := in.source.(io.Seeker); ok {
+		if _, err := seeker.Seek(pos, io.SeekStart); err != nil {
+			return err
+		}
+		in.buf.Reset(in.source)
+		in.pos = pos
+		return nil
+	}
+
+	if pos < in.pos {
+		return fmt.Errorf("Cannot seek backwards in a stream from %d to %d", in.pos, pos)
+	}
+	return in.discard(pos - in.pos)
+}
+
 ////////// DATA FILE WRITER
 
 // DataFileWriter lets you write object container files.
 type DataFileWriter struct {
-	output      io.Writer
+	output      *countingWriter
 	outputEnc   *BinaryEncoder
 	datumWriter DatumWriter
+	schema      Schema
 	sync        []byte
+	meta        map[string][]byte
+	codecName   string
+	level       int
+	codec       Codec
+
+	// the header is written lazily so the file can still be configured after construction
+	headerWritten bool
+
+	// set when this DataFileWriter opened the output itself and has to close it
+	closer io.Closer
 
 	// current block is buffered until flush
 	blockBuf   *bytes.Buffer
 	blockCount int64
 	blockEnc   *BinaryEncoder
+
+	// thresholds for flushing the current block automatically, zero disables them
+	syncInterval     int
+	blockRecordLimit int64
 }
 
 // NewDataFileWriter creates a new DataFileWriter for given output and schema using the given DatumWriter to write the data to that Writer.
-// May return an error if writing fails.
+// Blocks are not compressed unless a different codec is chosen with SetCodec before the first write.
+// May return an error if a sync marker cannot be generated.
 func NewDataFileWriter(output io.Writer, schema Schema, datumWriter DatumWriter) (writer *DataFileWriter, err error) {
-	encoder := NewBinaryEncoder(output)
 	datumWriter.SetSchema(schema)
-	sync := []byte("1234567890abcdef") // TODO come up with other sync value
-
-	header := &objFileHeader{
-		Magic: magic,
-		Meta: map[string][]byte{
-			schemaKey: []byte(schema.String()),
-			codecKey:  []byte("null"),
-		},
-		Sync: sync,
-	}
-	headerWriter := NewSpecificDatumWriter()
-	headerWriter.SetSchema(objHeaderSchema)
-	if err = headerWriter.Write(header, encoder); err != nil {
-		return
+	// every file gets its own random sync marker so that block boundaries can't be confused with other files
+	sync := make([]byte, syncSize)
+	if _, err = rand.Read(sync); err != nil {
+		return nil, err
 	}
+
+	counter := &countingWriter{writer: output}
 	blockBuf := &bytes.Buffer{}
 	writer = &DataFileWriter{
-		output:      output,
-		outputEnc:   encoder,
+		output:      counter,
+		outputEnc:   NewBinaryEncoder(counter),
 		datumWriter: datumWriter,
+		schema:      schema,
 		sync:        sync,
+		meta:        make(map[string][]byte),
+		codecName:   NullCodec,
+		level:       DefaultCompressionLevel,
+		codec:       nullCodec{},
 		blockBuf:    blockBuf,
 		blockEnc:    NewBinaryEncoder(blockBuf),
+
+		syncInterval: DefaultSyncInterval,
 	}
 
 	return
 }
 
+// OpenDataFileWriterForAppend opens an existing object container file to append datums to it using the given
+// DatumWriter. The header of the file is kept as is: new blocks use the codec and sync marker of the file.
+// Schema may be nil to use the schema of the file, otherwise it must be the same schema the file was written with.
+// The file is closed when the returned DataFileWriter is closed.
+// May return an error if the file cannot be opened, is not a valid data file or the schemas differ.
+func OpenDataFileWriterForAppend(filename string, schema Schema, datumWriter DatumWriter) (*DataFileWriter, error) {
+	file, err := os.OpenFile(filename, os.O_RDWR, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	writer, err := newDataFileWriterForAppend(file, schema, datumWriter)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	writer.closer = file
+
+	return writer, nil
+}
+
+func newDataFileWriterForAppend(file io.ReadWriteSeeker, schema Schema, datumWriter DatumWriter) (*DataFileWriter, error) {
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	header, err := newFileInput(file).readHeader()
+	if err != nil {
+		return nil, err
+	}
+	fileSchema, err := ParseSchema(string(header.Meta[schemaKey]))
+	if err != nil {
+		return nil, err
+	}
+	if schema == nil {
+		schema = fileSchema
+	} else if schema.String() != fileSchema.String() {
+		return nil, fmt.Errorf("Cannot append with schema %s to a data file written with schema %s", schema, fileSchema)
+	}
+
+	end, err := file.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, err
+	}
+
+	writer, err := NewDataFileWriter(file, schema, datumWriter)
+	if err != nil {
+		return nil, err
+	}
+	if codec, ok := header.Meta[codecKey]; ok && len(codec) > 0 {
+		if err = writer.SetCodec(string(codec)); err != nil {
+			return nil, err
+		}
+	}
+	writer.sync = header.Sync
+	writer.output.count = end
+	writer.headerWritten = true
+
+	return writer, nil
+}
+
+// SetSyncInterval sets the approximate size in bytes of uncompressed blocks: a block is flushed automatically once
+// it reaches this size. Defaults to DefaultSyncInterval, zero means blocks are only flushed explicitly.
+func (w *DataFileWriter) SetSyncInterval(size int) error {
+	if size < 0 {
+		return fmt.Errorf("Invalid sync interval: %d", size)
+	}
+
+	w.syncInterval = size
+	return nil
+}
+
+// SetBlockRecordLimit sets the maximum number of records in a block: a block is flushed automatically once it
+// holds this many records. Defaults to zero, which means there is no limit.
+func (w *DataFileWriter) SetBlockRecordLimit(count int64) error {
+	if count < 0 {
+		return fmt.Errorf("Invalid block record limit: %d", count)
+	}
+
+	w.blockRecordLimit = count
+	return nil
+}
+
+// SetCodec sets the codec used to compress blocks of this DataFileWriter, e.g. DeflateCodec or SnappyCodec.
+// The codec is looked up by name in the global registry, see RegisterCodec.
+// Must be called before the first datum is written.
+func (w *DataFileWriter) SetCodec(name string) error {
+	if w.headerWritten {
+		return HeaderAlreadyWritten
+	}
+
+	return w.useCodec(name, w.level)
+}
+
+// SetCompressionLevel sets the compression level used by codecs that support one, currently deflate and zstandard.
+// Valid levels depend on the codec, see NewDeflateCodec and NewZstandardCodec. Other codecs ignore it.
+// Must be called before the first datum is written.
+func (w *DataFileWriter) SetCompressionLevel(level int) error {
+	if w.headerWritten {
+		return HeaderAlreadyWritten
+	}
+
+	return w.useCodec(w.codecName, level)
+}
+
+func (w *DataFileWriter) useCodec(name string, level int) error {
+	codec, err := GetCodec(name)
+	if err != nil {
+		return err
+	}
+	if codec, err = withCompressionLevel(codec, level); err != nil {
+		return err
+	}
+
+	w.codecName, w.level, w.codec = name, level, codec
+	return nil
+}
+
+// SetMeta sets a metadata entry of the file header, e.g. to record the producer of the file.
+// Keys starting with "avro." are reserved and cannot be set.
+// Must be called before the first datum is written.
+func (w *DataFileWriter) SetMeta(key string, value []byte) error {
+	if w.headerWritten {
+		return HeaderAlreadyWritten
+	}
+	if strings.HasPrefix(key, reservedMetaPrefix) {
+		return fmt.Errorf("Reserved metadata key: %s", key)
+	}
+
+	w.meta[key] = value
+	return nil
+}
+
+func (w *DataFileWriter) writeHeader() error {
+	meta := make(map[string][]byte, len(w.meta)+2)
+	for key, value := range w.meta {
+		meta[key] = value
+	}
+	meta[schemaKey] = []byte(w.schema.String())
+	meta[codecKey] = []byte(w.codecName)
+
+	header := &objFileHeader{
+		Magic: magic,
+		Meta:  meta,
+		Sync:  w.sync,
+	}
+	headerWriter := NewSpecificDatumWriter()
+	headerWriter.SetSchema(objHeaderSchema)
+	if err := headerWriter.Write(header, w.outputEnc); err != nil {
+		return err
+	}
+	if w.output.err != nil {
+		return w.output.err
+	}
+
+	w.headerWritten = true
+	return nil
+}
+
 // Write out a single datum.
 //
 // Encoded datums are buffered internally and will not be written to the
-// underlying io.Writer until Flush() is called.
+// underlying io.Writer until Flush() is called or the current block reaches
+// the limits set by SetSyncInterval or SetBlockRecordLimit.
 func (w *DataFileWriter) Write(v interface{}) error {
+	if !w.headerWritten {
+		if err := w.writeHeader(); err != nil {
+			return err
+		}
+	}
+
+	size := w.blockBuf.Len()
+	if err := w.datumWriter.Write(v, w.blockEnc); err != nil {
+		// drop whatever was partially written so the block stays valid
+		w.blockBuf.Truncate(size)
+		return err
+	}
 	w.blockCount++
-	err := w.datumWriter.Write(v, w.blockEnc)
-	return err
+
+	if (w.syncInterval > 0 && w.blockBuf.Len() >= w.syncInterval) ||
+		(w.blockRecordLimit > 0 && w.blockCount >= w.blockRecordLimit) {
+		return w.actuallyFlush()
+	}
+	return nil
 }
 
 // Flush out any previously written datums to our underlying io.Writer.
 // Does nothing if no datums had previously been written.
 //
-// It's up to the library user to decide how often to flush; doing it
-// often will spend a lot of time on tiny I/O but save memory.
+// Blocks are flushed automatically according to SetSyncInterval and
+// SetBlockRecordLimit; flushing more often will spend a lot of time on
+// tiny I/O but save memory.
 func (w *DataFileWriter) Flush() error {
 	if w.blockCount > 0 {
 		return w.actuallyFlush()
@@ -261,19 +954,17 @@ func (w *DataFileWriter) Flush() error {
 }
 
 func (w *DataFileWriter) actuallyFlush() error {
-	// Write the block count and length directly to output
-	w.outputEnc.WriteLong(w.blockCount)
-	w.outputEnc.WriteLong(int64(w.blockBuf.Len()))
+	if !w.headerWritten {
+		if err := w.writeHeader(); err != nil {
+			return err
+		}
+	}
 
-	// copy the buffer which is the block buf to output
-	_, err := io.Copy(w.output, w.blockBuf)
+	block, err := w.codec.Compress(w.blockBuf.Bytes())
 	if err != nil {
 		return err
 	}
-
-	// write the sync bytes
-	_, err = w.output.Write(w.sync)
-	if err != nil {
+	if err = w.writeBlock(w.blockCount, block); err != nil {
 		return err
 	}
 
@@ -282,6 +973,78 @@ func (w *DataFileWriter) actuallyFlush() error {
 	return nil
 }
 
+func (w *DataFileWriter) writeBlock(count int64, block []byte) error {
+	// Write the block count and length directly to output
+	w.outputEnc.WriteLong(count)
+	w.outputEnc.WriteLong(int64(len(block)))
+	if w.output.err != nil {
+		return w.output.err
+	}
+
+	// copy the (possibly compressed) block to output
+	if _, err := w.output.Write(block); err != nil {
+		return err
+	}
+
+	// write the sync bytes
+	_, err := w.output.Write(w.sync)
+	return err
+}
+
+// AppendRawBlock writes a block read with DataFileReader.NextRawBlock without decoding its values, e.g. to
+// concatenate data files. Datums written before are flushed first. A block compressed with another codec than the
+// one of this DataFileWriter is decompressed and compressed again, the codec being looked up in the global registry.
+// Returns an error if the values of the block are written with a different schema than this DataFileWriter's.
+func (w *DataFileWriter) AppendRawBlock(block *RawBlock) error {
+	if block.Schema == nil || block.Schema.String() != w.schema.String() {
+		return fmt.Errorf("Cannot append a block written with schema %s to a data file with schema %s", block.Schema, w.schema)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if !w.headerWritten {
+		if err := w.writeHeader(); err != nil {
+			return err
+		}
+	}
+
+	data := block.Data
+	codecName := block.Codec
+	if codecName == "" {
+		codecName = NullCodec
+	}
+	if codecName != w.codecName {
+		codec, err := GetCodec(codecName)
+		if err != nil {
+			return err
+		}
+		if data, err = codec.Decompress(data); err != nil {
+			return err
+		}
+		if data, err = w.codec.Compress(data); err != nil {
+			return err
+		}
+	}
+
+	return w.writeBlock(block.Count, data)
+}
+
+// Sync ends the current block, if any, and returns the position in the output where the next block starts.
+// The position may be passed to DataFileReader.Seek to start reading from that block later.
+// May return an error if writing fails.
+func (w *DataFileWriter) Sync() (int64, error) {
+	if err := w.Flush(); err != nil {
+		return 0, err
+	}
+	if !w.headerWritten {
+		if err := w.writeHeader(); err != nil {
+			return 0, err
+		}
+	}
+
+	return w.output.count, nil
+}
+
 // Close this DataFileWriter.
 // This is required to finish out the data file format.
 // After Close() is called, this DataFileWriter cannot be used anymore.
@@ -297,5 +1060,30 @@ func (w *DataFileWriter) Close() error {
 			w.blockBuf, w.blockEnc = nil, nil
 		}
 	}
+	if w.closer != nil {
+		if closeErr := w.closer.Close(); err == nil {
+			err = closeErr
+		}
+		w.closer = nil
+	}
 	return err
 }
+
+// countingWriter keeps track of the number of bytes written to the underlying io.Writer as well as the first error
+// that happened, since Encoder does not report errors.
+type countingWriter struct {
+	writer io.Writer
+	count  int64
+	err    error
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	if cw.err != nil {
+		return 0, cw.err
+	}
+
+	n, err := cw.writer.Write(p)
+	cw.count += int64(n)
+	cw.err = err
+	return n, err
+}
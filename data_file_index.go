@@ -0,0 +1,195 @@
+package avro
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+)
+
+// BlockIndexMetaKey is the metadata key a BlockIndex of a data file may be stored under in the file header, see
+// DataFileWriter.SetBlockIndex. Being in the reserved "avro." namespace, it cannot collide with keys set by SetMeta.
+const BlockIndexMetaKey = "avro.go.index"
+
+const blockIndexSchemaRaw = `{"type": "record", "name": "BlockIndex",
+ "fields" : [
+   {"name": "offsets", "type": {"type": "array", "items": "long"}},
+   {"name": "counts", "type": {"type": "array", "items": "long"}}
+  ]
+}`
+
+var blockIndexSchema = MustParseSchema(blockIndexSchemaRaw)
+
+// BlockIndex maps the values of a data file to the blocks containing them, allowing to seek to any value without
+// scanning the file, see DataFileReader.SeekToRecord.
+// Block positions are kept relative to the first block, so an index stays valid if only the file header changes, e.g.
+// when blocks are copied to a new file using DataFileWriter.AppendRawBlock with the index stored in the header.
+type BlockIndex struct {
+	// position of each block relative to the first block and the number of values before it
+	offsets []int64
+	firsts  []int64
+	count   int64
+}
+
+// the form a BlockIndex is stored in
+type storedBlockIndex struct {
+	Offsets []int64 `avro:"offsets"`
+	Counts  []int64 `avro:"counts"`
+}
+
+// Count returns the number of values in the indexed file.
+func (index *BlockIndex) Count() int64 {
+	return index.count
+}
+
+func (index *BlockIndex) add(offset int64, count int64) {
+	index.offsets = append(index.offsets, offset)
+	index.firsts = append(index.firsts, index.count)
+	index.count += count
+}
+
+// find returns the position of the block containing the n-th value relative to the first block and the number of
+// values in the block before it.
+func (index *BlockIndex) find(n int64) (int64, int64) {
+	block := sort.Search(len(index.firsts), func(i int) bool { return index.firsts[i] > n }) - 1
+	return index.offsets[block], n - index.firsts[block]
+}
+
+// MarshalBinary encodes this BlockIndex to be stored in a sidecar file or the header of its data file.
+func (index *BlockIndex) MarshalBinary() ([]byte, error) {
+	stored := &storedBlockIndex{Offsets: index.offsets, Counts: make([]int64, len(index.offsets))}
+	for i := range index.offsets {
+		next := index.count
+		if i+1 < len(index.firsts) {
+			next = index.firsts[i+1]
+		}
+		stored.Counts[i] = next - index.firsts[i]
+	}
+
+	buf := &bytes.Buffer{}
+	writer := NewSpecificDatumWriter()
+	writer.SetSchema(blockIndexSchema)
+	if err := writer.Write(stored, NewBinaryEncoder(buf)); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// UnmarshalBinary decodes a BlockIndex encoded with MarshalBinary.
+func (index *BlockIndex) UnmarshalBinary(data []byte) error {
+	stored := &storedBlockIndex{}
+	reader := NewSpecificDatumReader()
+	reader.SetSchema(blockIndexSchema)
+	if err := reader.Read(stored, NewBinaryDecoder(data)); err != nil {
+		return err
+	}
+	if len(stored.Offsets) != len(stored.Counts) {
+		return fmt.Errorf("Invalid block index: %d offsets for %d blocks", len(stored.Offsets), len(stored.Counts))
+	}
+
+	for i, offset := range stored.Offsets {
+		if offset < 0 || i > 0 && offset <= stored.Offsets[i-1] {
+			return fmt.Errorf("Invalid block index: offset %d of block %d is not after the one before", offset, i)
+		}
+		if stored.Counts[i] < 0 {
+			return fmt.Errorf("Invalid block index: negative count %d of block %d", stored.Counts[i], i)
+		}
+	}
+
+	*index = BlockIndex{}
+	for i, offset := range stored.Offsets {
+		index.add(offset, stored.Counts[i])
+	}
+	return nil
+}
+
+// BuildBlockIndex scans all blocks of this DataFileReader's file, without decoding them, to build a BlockIndex.
+// Moves this DataFileReader to the end of the file, Seek or SeekToRecord may be used to continue reading.
+// Streams must not have been read from before.
+func (reader *DataFileReader) BuildBlockIndex() (*BlockIndex, error) {
+	if err := reader.Seek(reader.dataStart); err != nil {
+		return nil, err
+	}
+
+	index := &BlockIndex{}
+	var block RawBlock
+	for {
+		ok, err := reader.NextRawBlock(&block)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return index, nil
+		}
+		index.add(reader.blockStart-reader.dataStart, block.Count)
+	}
+}
+
+// SetBlockIndex sets the BlockIndex used by SeekToRecord, e.g. one loaded from a sidecar file.
+func (reader *DataFileReader) SetBlockIndex(index *BlockIndex) {
+	reader.index = index
+}
+
+// SetBlockIndex stores the given BlockIndex in the file header under BlockIndexMetaKey, e.g. the index of a file
+// whose blocks are copied with AppendRawBlock. Must be called before the first datum is written.
+func (w *DataFileWriter) SetBlockIndex(index *BlockIndex) error {
+	if w.headerWritten {
+		return HeaderAlreadyWritten
+	}
+	stored, err := index.MarshalBinary()
+	if err != nil {
+		return err
+	}
+
+	w.meta[BlockIndexMetaKey] = stored
+	return nil
+}
+
+// SeekToRecord moves this DataFileReader to the n-th value of its file, counting from zero, so the next call to Next
+// reads that value. Uses the BlockIndex set with SetBlockIndex or stored in the file header under BlockIndexMetaKey,
+// otherwise builds one by scanning the file once.
+// May return an error if there is no such value or the index does not match the file.
+func (reader *DataFileReader) SeekToRecord(n int64) error {
+	if reader.index == nil {
+		if stored, ok := reader.header.Meta[BlockIndexMetaKey]; ok {
+			index := &BlockIndex{}
+			if err := index.UnmarshalBinary(stored); err != nil {
+				return err
+			}
+			reader.index = index
+		} else {
+			index, err := reader.BuildBlockIndex()
+			if err != nil {
+				return err
+			}
+			reader.index = index
+		}
+	}
+	if n < 0 || n >= reader.index.count {
+		return fmt.Errorf("Record index out of range: %d", n)
+	}
+
+	offset, skip := reader.index.find(n)
+	if err := reader.Seek(reader.dataStart + offset); err != nil {
+		return err
+	}
+	if err := reader.NextBlock(); err != nil {
+		return err
+	}
+	if skip >= reader.block.BlockRemaining {
+		return fmt.Errorf("Block index does not match the file at record %d", n)
+	}
+
+	if skip > 0 && reader.skip == nil {
+		var err error
+		if reader.skip, err = newResolveFunc(reader.schema, reader.schema); err != nil {
+			return err
+		}
+	}
+	for ; skip > 0; skip-- {
+		if err := reader.skip(reader.blockDecoder, discardEncoder); err != nil {
+			return err
+		}
+		reader.block.BlockRemaining--
+	}
+	return nil
+}
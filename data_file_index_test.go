@@ -0,0 +1,120 @@
+package avro
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestDataFileSeekToRecord(t *testing.T) {
+	encoded := writeTestDataFile(t, 1000, 23)
+
+	dfr, err := newDataFileReaderBytes(encoded, NewSpecificDatumReader())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, n := range []int64{500, 0, 999, 22, 23, 24, 460} {
+		assert(t, dfr.SeekToRecord(n), nil)
+		var p primitive
+		ok, err := dfr.Next(&p)
+		assert(t, err, nil)
+		assert(t, ok, true)
+		assert(t, p.LongField, n)
+	}
+	// reading goes on from there
+	ok, err := dfr.Next(&primitive{})
+	assert(t, ok, true)
+	assert(t, err, nil)
+
+	if err = dfr.SeekToRecord(1000); err == nil {
+		t.Fatal("Expected an error seeking past the last record")
+	}
+
+	index, err := dfr.BuildBlockIndex()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert(t, index.Count(), int64(1000))
+
+	// as a sidecar
+	stored, err := index.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	loaded := &BlockIndex{}
+	assert(t, loaded.UnmarshalBinary(stored), nil)
+	assert(t, loaded, index)
+	dfr, err = newDataFileReaderBytes(encoded, NewSpecificDatumReader())
+	if err != nil {
+		t.Fatal(err)
+	}
+	dfr.SetBlockIndex(loaded)
+	assert(t, dfr.SeekToRecord(777), nil)
+	var p primitive
+	_, err = dfr.Next(&p)
+	assert(t, err, nil)
+	assert(t, p.LongField, int64(777))
+
+	for _, invalid := range []storedBlockIndex{
+		{Offsets: []int64{0, 100}, Counts: []int64{10, -1}},
+		{Offsets: []int64{-5, 100}, Counts: []int64{10, 10}},
+		{Offsets: []int64{0, 100, 100}, Counts: []int64{10, 10, 10}},
+		{Offsets: []int64{0, 100, 50}, Counts: []int64{10, 10, 10}},
+	} {
+		buf := &bytes.Buffer{}
+		writer := NewSpecificDatumWriter()
+		writer.SetSchema(blockIndexSchema)
+		if err := writer.Write(&invalid, NewBinaryEncoder(buf)); err != nil {
+			t.Fatal(err)
+		}
+		assert(t, loaded.UnmarshalBinary(buf.Bytes()) != nil, true)
+	}
+	assert(t, loaded, index)
+}
+
+func TestDataFileBlockIndexInHeader(t *testing.T) {
+	encoded := writeTestDataFile(t, 300, 40)
+	dfr, err := newDataFileReaderBytes(encoded, NewSpecificDatumReader())
+	if err != nil {
+		t.Fatal(err)
+	}
+	index, err := dfr.BuildBlockIndex()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// copy the blocks to a file with the index in its header
+	buf := &bytes.Buffer{}
+	dfw, err := NewDataFileWriter(buf, dfr.Schema(), NewSpecificDatumWriter())
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert(t, dfw.SetBlockIndex(index), nil)
+	// user metadata cannot be taken for the index
+	assert(t, dfw.SetMeta("index", []byte("user data")), nil)
+	if err = dfw.SetMeta(BlockIndexMetaKey, []byte("user data")); err == nil {
+		t.Fatal("Expected an error setting the block index key as metadata")
+	}
+	assert(t, dfr.Seek(dfr.dataStart), nil)
+	var block RawBlock
+	for {
+		ok, err := dfr.NextRawBlock(&block)
+		assert(t, err, nil)
+		if !ok {
+			break
+		}
+		assert(t, dfw.AppendRawBlock(&block), nil)
+	}
+	assert(t, dfw.Close(), nil)
+
+	dfr, err = newDataFileReaderBytes(buf.Bytes(), NewSpecificDatumReader())
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert(t, dfr.SeekToRecord(123), nil)
+	// the stored index is used rather than scanning the file
+	assert(t, dfr.index, index)
+	var p primitive
+	_, err = dfr.Next(&p)
+	assert(t, err, nil)
+	assert(t, p.LongField, int64(123))
+}
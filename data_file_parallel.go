@@ -0,0 +1,338 @@
+package avro
+
+import (
+	"bytes"
+	"fmt"
+	"runtime"
+	"sync"
+)
+
+// ParallelDataFileReader reads the values of a DataFileReader decoding several blocks at once.
+// A single goroutine reads blocks ahead while a pool of workers decompresses and decodes them.
+// Values are delivered in the order of the file unless told otherwise, see SetUnordered.
+//
+// The DatumReader of the DataFileReader must be safe for concurrent use, like SpecificDatumReader and
+// GenericDatumReader are once their schema is set. The DataFileReader must not be used directly while its values are
+// read in parallel and it is positioned at an unspecified block afterwards.
+//
+// The recovery mode of the DataFileReader is kept, see DataFileReader.SetRecoveryHandler. Its handler is called from
+// the goroutines of the ParallelDataFileReader, one call at a time, but not necessarily in the order of the file.
+type ParallelDataFileReader struct {
+	reader    *DataFileReader
+	newValue  func() interface{}
+	workers   int
+	readAhead int
+	unordered bool
+
+	started bool
+	closed  bool
+	done    chan struct{}
+	wg      sync.WaitGroup
+	// ordered mode: one channel per block in the order of the file
+	pending chan chan decodedBlock
+	// unordered mode: decoded blocks in the order they are finished, and the blocks not delivered yet
+	results  chan decodedBlock
+	decoding sync.WaitGroup
+	// serializes calls of the recovery handler
+	recovering sync.Mutex
+
+	values []interface{}
+	err    error
+}
+
+type blockJob struct {
+	start        int64
+	count        int64
+	data         []byte
+	decompressed bool
+	result       chan<- decodedBlock
+}
+
+type decodedBlock struct {
+	values []interface{}
+	err    error
+}
+
+// NewParallelDataFileReader creates a ParallelDataFileReader reading the remaining values of the given DataFileReader
+// with the given number of workers, or one per CPU if workers is not positive. Each value is created with newValue,
+// which must return a pointer suitable for the DatumReader of the DataFileReader.
+func NewParallelDataFileReader(reader *DataFileReader, newValue func() interface{}, workers int) *ParallelDataFileReader {
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	return &ParallelDataFileReader{
+		reader:    reader,
+		newValue:  newValue,
+		workers:   workers,
+		readAhead: 2 * workers,
+		done:      make(chan struct{}),
+	}
+}
+
+// SetUnordered tells this ParallelDataFileReader to deliver values as soon as their block is decoded instead of in
+// the order of the file. Values of a single block always keep their order.
+// Must be called before reading any data.
+func (p *ParallelDataFileReader) SetUnordered(unordered bool) {
+	p.unordered = unordered
+}
+
+// SetReadAhead sets the maximum number of blocks read from the file that are not yet delivered.
+// Defaults to twice the number of workers. Must be called before reading any data.
+func (p *ParallelDataFileReader) SetReadAhead(blocks int) {
+	if blocks > 0 {
+		p.readAhead = blocks
+	}
+}
+
+// Next returns the next value of the file.
+// Second return value indicates whether there was a value, it is false when no more data left to read.
+// Third return value indicates whether there was an error while reading data, which stops reading.
+func (p *ParallelDataFileReader) Next() (interface{}, bool, error) {
+	if p.closed {
+		return nil, false, nil
+	}
+	if !p.started {
+		p.start()
+	}
+
+	for len(p.values) == 0 {
+		if p.err != nil {
+			return nil, false, p.err
+		}
+
+		block, ok := p.nextBlock()
+		if !ok {
+			return nil, false, nil
+		}
+		p.values, p.err = block.values, block.err
+	}
+
+	value := p.values[0]
+	p.values[0] = nil
+	p.values = p.values[1:]
+	return value, true, nil
+}
+
+func (p *ParallelDataFileReader) nextBlock() (decodedBlock, bool) {
+	if p.unordered {
+		block, ok := <-p.results
+		return block, ok
+	}
+
+	result, ok := <-p.pending
+	if !ok {
+		return decodedBlock{}, false
+	}
+	return <-result, true
+}
+
+// Close stops reading and waits for all goroutines of this ParallelDataFileReader to finish.
+// Next returns no more values afterwards.
+func (p *ParallelDataFileReader) Close() error {
+	if p.closed {
+		return nil
+	}
+
+	p.closed = true
+	p.values = nil
+	close(p.done)
+	p.wg.Wait()
+	return nil
+}
+
+func (p *ParallelDataFileReader) start() {
+	p.started = true
+	jobs := make(chan blockJob, p.readAhead)
+	if p.unordered {
+		p.results = make(chan decodedBlock, p.readAhead)
+	} else {
+		p.pending = make(chan chan decodedBlock, p.readAhead)
+	}
+
+	var producers sync.WaitGroup
+	producers.Add(p.workers + 1)
+	p.wg.Add(p.workers + 1)
+	go func() {
+		defer p.wg.Done()
+		defer producers.Done()
+		p.readBlocks(jobs)
+	}()
+	for i := 0; i < p.workers; i++ {
+		go func() {
+			defer p.wg.Done()
+			defer producers.Done()
+			p.decodeBlocks(jobs)
+		}()
+	}
+
+	if p.unordered {
+		go func() {
+			producers.Wait()
+			close(p.results)
+		}()
+	}
+}
+
+// readBlocks reads raw blocks from the file and hands them to the workers until the end of the file or an error.
+func (p *ParallelDataFileReader) readBlocks(jobs chan<- blockJob) {
+	defer close(jobs)
+	if !p.unordered {
+		defer close(p.pending)
+	}
+
+	reader := p.reader
+	if _, err := reader.getCodec(); err != nil {
+		p.fail(err)
+		return
+	}
+
+	// the rest of the block the DataFileReader is at, already decompressed
+	if block := reader.block; block.BlockRemaining > 0 {
+		data := block.Data[reader.blockDecoder.Tell():block.BlockSize]
+		job := blockJob{start: reader.blockStart, count: block.BlockRemaining, data: append([]byte(nil), data...),
+			decompressed: true}
+		block.BlockRemaining = 0
+		reader.blockDecoder.Seek(int64(block.BlockSize))
+		if !p.submit(jobs, job) {
+			return
+		}
+	}
+
+	for {
+		hasNextBlock, err := reader.hasNextBlock()
+		if err != nil {
+			p.fail(err)
+			return
+		}
+		if !hasNextBlock {
+			return
+		}
+		start := reader.input.pos
+		reader.blockStart = start
+		count, compressed, err := reader.readRawBlock()
+		if err == nil && count < 0 {
+			err = fmt.Errorf("Block count invalid: %d", count)
+		}
+		if err != nil {
+			p.recovering.Lock()
+			err = reader.recover(err)
+			p.recovering.Unlock()
+			if err != nil {
+				p.fail(err)
+				return
+			}
+			continue
+		}
+		if count == 0 {
+			continue
+		}
+		// blocks are reused by the DataFileReader, so hand out a copy
+		if !p.submit(jobs, blockJob{start: start, count: count, data: append([]byte(nil), compressed...)}) {
+			return
+		}
+	}
+}
+
+// submit queues a block for the workers, returns false if reading was stopped.
+func (p *ParallelDataFileReader) submit(jobs chan<- blockJob, job blockJob) bool {
+	if p.unordered {
+		job.result = p.results
+		p.decoding.Add(1)
+	} else {
+		result := make(chan decodedBlock, 1)
+		job.result = result
+		select {
+		case p.pending <- result:
+		case <-p.done:
+			return false
+		}
+	}
+
+	select {
+	case jobs <- job:
+		return true
+	case <-p.done:
+		if p.unordered {
+			p.decoding.Done()
+		}
+		return false
+	}
+}
+
+// fail delivers an error of reading the file after all blocks read before.
+func (p *ParallelDataFileReader) fail(err error) {
+	failed := decodedBlock{err: err}
+	if p.unordered {
+		// blocks still being decoded must not be overtaken by the error
+		p.decoding.Wait()
+		select {
+		case p.results <- failed:
+		case <-p.done:
+		}
+		return
+	}
+
+	result := make(chan decodedBlock, 1)
+	result <- failed
+	select {
+	case p.pending <- result:
+	case <-p.done:
+	}
+}
+
+func (p *ParallelDataFileReader) decodeBlocks(jobs <-chan blockJob) {
+	resolved := &bytes.Buffer{}
+	for job := range jobs {
+		// once stopped, jobs are only drained so that a pending failure stops waiting for them
+		select {
+		case <-p.done:
+		default:
+			block := p.decodeBlock(job, resolved)
+			select {
+			case job.result <- block:
+			case <-p.done:
+			}
+		}
+		if p.unordered {
+			p.decoding.Done()
+		}
+	}
+}
+
+func (p *ParallelDataFileReader) decodeBlock(job blockJob, resolved *bytes.Buffer) decodedBlock {
+	data := job.data
+	if !job.decompressed {
+		var err error
+		if data, err = p.reader.codec.Decompress(data); err != nil {
+			return p.recover(job, nil, err)
+		}
+	}
+
+	dec := NewBinaryDecoder(data)
+	values := make([]interface{}, job.count)
+	for i := range values {
+		values[i] = p.newValue()
+		if err := p.reader.readDatum(values[i], dec, resolved); err != nil {
+			return p.recover(job, values[:i], err)
+		}
+	}
+	if dec.Tell() != int64(len(data)) {
+		return p.recover(job, values, BlockNotFinished)
+	}
+
+	return decodedBlock{values: values}
+}
+
+// recover reports a block that failed to be decoded in recovery mode, keeping the values decoded before like
+// DataFileReader does, or else fails with the error.
+func (p *ParallelDataFileReader) recover(job blockJob, values []interface{}, err error) decodedBlock {
+	if p.reader.recovery == nil {
+		return decodedBlock{err: err}
+	}
+
+	p.recovering.Lock()
+	p.reader.recovery(job.start, err)
+	p.recovering.Unlock()
+	return decodedBlock{values: values}
+}
@@ -0,0 +1,180 @@
+package avro
+
+import (
+	"bytes"
+	"testing"
+)
+
+func newPrimitive() interface{} {
+	return &primitive{}
+}
+
+func TestParallelDataFileReader(t *testing.T) {
+	encoded := writeTestDataFile(t, 1000, 37)
+
+	dfr, err := newDataFileReaderBytes(encoded, NewSpecificDatumReader())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// start in the middle of a block
+	for i := 0; i < 10; i++ {
+		ok, err := dfr.Next(&primitive{})
+		assert(t, err, nil)
+		assert(t, ok, true)
+	}
+
+	parallel := NewParallelDataFileReader(dfr, newPrimitive, 4)
+	parallel.SetReadAhead(3)
+	for i := 10; i < 1000; i++ {
+		value, ok, err := parallel.Next()
+		assert(t, err, nil)
+		assert(t, ok, true)
+		assert(t, value.(*primitive).LongField, int64(i))
+	}
+	_, ok, err := parallel.Next()
+	assert(t, ok, false)
+	assert(t, err, nil)
+	assert(t, parallel.Close(), nil)
+}
+
+func TestParallelDataFileReaderUnordered(t *testing.T) {
+	encoded := writeTestDataFile(t, 1000, 10)
+
+	dfr, err := newDataFileReaderBytes(encoded, NewGenericDatumReader())
+	if err != nil {
+		t.Fatal(err)
+	}
+	readerSchema := MustParseSchema(`{"type": "record", "name": "Primitive", "fields": [
+		{"name": "longField", "type": "double"}
+	]}`)
+	assert(t, dfr.SetReaderSchema(readerSchema), nil)
+
+	parallel := NewParallelDataFileReader(dfr, func() interface{} { return NewGenericRecord(readerSchema) }, 0)
+	parallel.SetUnordered(true)
+	seen := make(map[float64]bool)
+	for {
+		value, ok, err := parallel.Next()
+		assert(t, err, nil)
+		if !ok {
+			break
+		}
+		seen[value.(*GenericRecord).Get("longField").(float64)] = true
+	}
+	assert(t, len(seen), 1000)
+	for i := 0; i < 1000; i++ {
+		assert(t, seen[float64(i)], true)
+	}
+	assert(t, parallel.Close(), nil)
+}
+
+func TestParallelDataFileReaderErrors(t *testing.T) {
+	encoded := writeTestDataFile(t, 1000, 10)
+
+	// stopping early must not block
+	dfr, err := newDataFileReaderBytes(encoded, NewSpecificDatumReader())
+	if err != nil {
+		t.Fatal(err)
+	}
+	parallel := NewParallelDataFileReader(dfr, newPrimitive, 2)
+	_, ok, err := parallel.Next()
+	assert(t, ok, true)
+	assert(t, err, nil)
+	assert(t, parallel.Close(), nil)
+	_, ok, err = parallel.Next()
+	assert(t, ok, false)
+	assert(t, err, nil)
+
+	// values before a corrupted block are delivered, then the error
+	dfr, err = newDataFileReaderBytes(encoded, NewSpecificDatumReader())
+	if err != nil {
+		t.Fatal(err)
+	}
+	corrupted := append([]byte(nil), encoded...)
+	last := bytes.LastIndex(corrupted, dfr.header.Sync)
+	// the sync marker of the last block with values, followed by the empty block written on Close
+	corrupted[bytes.LastIndex(corrupted[:last], dfr.header.Sync)] ^= 0xFF
+	if dfr, err = newDataFileReaderBytes(corrupted, NewSpecificDatumReader()); err != nil {
+		t.Fatal(err)
+	}
+	parallel = NewParallelDataFileReader(dfr, newPrimitive, 3)
+	count := 0
+	for {
+		value, ok, err := parallel.Next()
+		if err != nil {
+			assert(t, err, InvalidSync)
+			break
+		}
+		if !ok {
+			t.Fatal("Expected an error reading a corrupted file")
+		}
+		assert(t, value.(*primitive).LongField, int64(count))
+		count++
+	}
+	assert(t, count, 990)
+	assert(t, parallel.Close(), nil)
+
+	// also when unordered, the error is delivered after all values before it
+	if dfr, err = newDataFileReaderBytes(corrupted, NewSpecificDatumReader()); err != nil {
+		t.Fatal(err)
+	}
+	parallel = NewParallelDataFileReader(dfr, newPrimitive, 3)
+	parallel.SetUnordered(true)
+	count = 0
+	for {
+		_, ok, err := parallel.Next()
+		if err != nil {
+			assert(t, err, InvalidSync)
+			break
+		}
+		if !ok {
+			t.Fatal("Expected an error reading a corrupted file")
+		}
+		count++
+	}
+	assert(t, count, 990)
+	assert(t, parallel.Close(), nil)
+}
+
+func TestParallelDataFileReaderRecovery(t *testing.T) {
+	encoded := writeTestDataFile(t, 100, 10)
+	dfr, err := newDataFileReaderBytes(encoded, NewSpecificDatumReader())
+	if err != nil {
+		t.Fatal(err)
+	}
+	blocks := syncPositions(encoded, dfr.header.Sync)
+
+	// a broken sync marker loses its block and the next one, a block with more values than it contains the rest
+	brokenSync := append([]byte(nil), encoded...)
+	brokenSync[blocks[4]-1] ^= 0xFF
+	tooManyValues := append([]byte(nil), encoded...)
+	tooManyValues[blocks[2]] = 22
+	for _, c := range []struct {
+		corrupted []byte
+		count     int
+		offset    int
+	}{{brokenSync, 80, blocks[3]}, {tooManyValues, 100, blocks[2]}} {
+		for _, unordered := range []bool{false, true} {
+			if dfr, err = newDataFileReaderBytes(c.corrupted, NewSpecificDatumReader()); err != nil {
+				t.Fatal(err)
+			}
+			var offsets []int64
+			dfr.SetRecoveryHandler(func(offset int64, err error) {
+				offsets = append(offsets, offset)
+			})
+			parallel := NewParallelDataFileReader(dfr, newPrimitive, 3)
+			parallel.SetUnordered(unordered)
+			count := 0
+			for {
+				_, ok, err := parallel.Next()
+				assert(t, err, nil)
+				if !ok {
+					break
+				}
+				count++
+			}
+			assert(t, parallel.Close(), nil)
+			assert(t, count, c.count)
+			assert(t, offsets, []int64{int64(c.offset)})
+		}
+	}
+}
@@ -0,0 +1,191 @@
+package avro
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// FileFactory creates the files a RollingDataFileWriter writes to, given the sequence number of the file starting
+// from zero. Returns the file along with its name, which is reported once the file is finished.
+type FileFactory func(seq int) (io.WriteCloser, string, error)
+
+// NumberedFiles returns a FileFactory creating files named by formatting the given pattern with the sequence number
+// of the file, e.g. "events-%05d.avro".
+func NumberedFiles(pattern string) FileFactory {
+	return func(seq int) (io.WriteCloser, string, error) {
+		name := fmt.Sprintf(pattern, seq)
+		file, err := os.Create(name)
+		if err != nil {
+			return nil, "", err
+		}
+		return file, name, nil
+	}
+}
+
+// RollingPolicy tells a RollingDataFileWriter when to finish a file and start the next one.
+// Zero values mean no limit.
+type RollingPolicy struct {
+	// MaxSize is the approximate size of a file in bytes, data not yet flushed counts uncompressed.
+	MaxSize int64
+
+	// MaxRecords is the maximum number of datums in a file.
+	MaxRecords int64
+
+	// MaxAge is the time after which a file is finished, measured from its first datum. The age is only checked when
+	// a datum is written or on Flush, so an idle writer keeps its file open until then.
+	MaxAge time.Duration
+}
+
+// FinishedFile describes a file completed by a RollingDataFileWriter.
+type FinishedFile struct {
+	// Name is the name given by the FileFactory.
+	Name string
+
+	// Records is the number of datums in the file.
+	Records int64
+
+	// Size is the size of the file in bytes.
+	Size int64
+}
+
+// RollingDataFileWriter writes datums to a sequence of object container files, finishing a file and starting the
+// next one according to a RollingPolicy. Every file has its own header and is closed properly, so each of them is a
+// valid data file on its own. Files are only created once a datum is written to them.
+type RollingDataFileWriter struct {
+	files       FileFactory
+	schema      Schema
+	datumWriter DatumWriter
+	policy      RollingPolicy
+	setup       func(*DataFileWriter) error
+	finished    func(FinishedFile)
+
+	seq     int
+	file    io.WriteCloser
+	name    string
+	writer  *DataFileWriter
+	records int64
+	opened  time.Time
+
+	// replaced in tests
+	now func() time.Time
+}
+
+// NewRollingDataFileWriter creates a new RollingDataFileWriter writing datums of the given schema with the given
+// DatumWriter to files created by the given FileFactory according to the given RollingPolicy.
+func NewRollingDataFileWriter(files FileFactory, schema Schema, datumWriter DatumWriter, policy RollingPolicy) *RollingDataFileWriter {
+	return &RollingDataFileWriter{
+		files:       files,
+		schema:      schema,
+		datumWriter: datumWriter,
+		policy:      policy,
+		now:         time.Now,
+	}
+}
+
+// SetWriterSetup sets a function called with the DataFileWriter of every new file before anything is written to it,
+// e.g. to set the codec or metadata of all files.
+func (w *RollingDataFileWriter) SetWriterSetup(setup func(*DataFileWriter) error) {
+	w.setup = setup
+}
+
+// SetFinishedHandler sets a function called for every file once it is finished and closed.
+func (w *RollingDataFileWriter) SetFinishedHandler(handler func(FinishedFile)) {
+	w.finished = handler
+}
+
+// Write writes a single datum, finishing the current file before if it is older than the MaxAge of the policy and
+// after if it reached the MaxSize or MaxRecords of the policy.
+func (w *RollingDataFileWriter) Write(v interface{}) error {
+	if w.expired() {
+		if err := w.Roll(); err != nil {
+			return err
+		}
+	}
+	if w.writer == nil {
+		if err := w.open(); err != nil {
+			return err
+		}
+	}
+
+	if err := w.writer.Write(v); err != nil {
+		return err
+	}
+	w.records++
+
+	if (w.policy.MaxRecords > 0 && w.records >= w.policy.MaxRecords) ||
+		(w.policy.MaxSize > 0 && w.writer.output.count+int64(w.writer.blockBuf.Len()) >= w.policy.MaxSize) {
+		return w.Roll()
+	}
+	return nil
+}
+
+// Flush finishes the current file if it is older than the MaxAge of the policy, or else flushes its current block.
+// Should be called periodically, e.g. driven by a time.Ticker, for files to be finished in time while no datums are
+// written.
+func (w *RollingDataFileWriter) Flush() error {
+	if w.writer == nil {
+		return nil
+	}
+	if w.expired() {
+		return w.Roll()
+	}
+	return w.writer.Flush()
+}
+
+// expired checks whether the current file is older than the MaxAge of the policy.
+func (w *RollingDataFileWriter) expired() bool {
+	return w.writer != nil && w.policy.MaxAge > 0 && w.now().Sub(w.opened) >= w.policy.MaxAge
+}
+
+func (w *RollingDataFileWriter) open() error {
+	file, name, err := w.files(w.seq)
+	if err != nil {
+		return err
+	}
+
+	writer, err := NewDataFileWriter(file, w.schema, w.datumWriter)
+	if err == nil && w.setup != nil {
+		err = w.setup(writer)
+	}
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	w.seq++
+	w.file, w.name, w.writer = file, name, writer
+	w.records = 0
+	w.opened = w.now()
+	return nil
+}
+
+// Roll finishes the current file, if any, so the next datum goes to a new file. May be called periodically to finish
+// files in time even if no datums are written.
+func (w *RollingDataFileWriter) Roll() error {
+	if w.writer == nil {
+		return nil
+	}
+
+	output := w.writer.output
+	err := w.writer.Close()
+	if closeErr := w.file.Close(); err == nil {
+		err = closeErr
+	}
+	name := w.name
+	w.file, w.name, w.writer = nil, "", nil
+	if err != nil {
+		return err
+	}
+
+	if w.finished != nil {
+		w.finished(FinishedFile{Name: name, Records: w.records, Size: output.count})
+	}
+	return nil
+}
+
+// Close finishes the current file. The RollingDataFileWriter cannot be used anymore afterwards.
+func (w *RollingDataFileWriter) Close() error {
+	return w.Roll()
+}
@@ -0,0 +1,167 @@
+package avro
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type memoryFile struct {
+	bytes.Buffer
+	closed bool
+}
+
+func (f *memoryFile) Close() error {
+	f.closed = true
+	return nil
+}
+
+func rollingWriterForTest(policy RollingPolicy) (*RollingDataFileWriter, *[]*memoryFile, *[]FinishedFile) {
+	files := &[]*memoryFile{}
+	finished := &[]FinishedFile{}
+	w := NewRollingDataFileWriter(func(seq int) (io.WriteCloser, string, error) {
+		file := &memoryFile{}
+		*files = append(*files, file)
+		return file, fmt.Sprintf("file-%d", seq), nil
+	}, MustParseSchema(primitiveSchemaRaw), NewSpecificDatumWriter(), policy)
+	w.SetFinishedHandler(func(file FinishedFile) {
+		*finished = append(*finished, file)
+	})
+	return w, files, finished
+}
+
+func readMemoryFile(t *testing.T, file *memoryFile) []int64 {
+	if !file.closed {
+		t.Fatal("Expected file to be closed")
+	}
+	dfr, err := newDataFileReaderBytes(file.Bytes(), NewSpecificDatumReader())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var values []int64
+	for {
+		var p primitive
+		ok, err := dfr.Next(&p)
+		assert(t, err, nil)
+		if !ok {
+			return values
+		}
+		values = append(values, p.LongField)
+	}
+}
+
+func TestRollingDataFileWriterRecords(t *testing.T) {
+	w, files, finished := rollingWriterForTest(RollingPolicy{MaxRecords: 40})
+	w.SetWriterSetup(func(writer *DataFileWriter) error {
+		return writer.SetCodec(DeflateCodec)
+	})
+	for i := 0; i < 100; i++ {
+		assert(t, w.Write(&primitive{LongField: int64(i)}), nil)
+	}
+	assert(t, len(*files), 3)
+	assert(t, len(*finished), 2)
+	assert(t, w.Close(), nil)
+	assert(t, len(*finished), 3)
+
+	next := int64(0)
+	for i, file := range *files {
+		values := readMemoryFile(t, file)
+		assert(t, (*finished)[i].Name, fmt.Sprintf("file-%d", i))
+		assert(t, (*finished)[i].Records, int64(len(values)))
+		assert(t, (*finished)[i].Size, int64(file.Len()))
+		for _, value := range values {
+			assert(t, value, next)
+			next++
+		}
+	}
+	assert(t, next, int64(100))
+	assert(t, []int{len(readMemoryFile(t, (*files)[0])), len(readMemoryFile(t, (*files)[2]))}, []int{40, 20})
+}
+
+func TestRollingDataFileWriterSizeAndAge(t *testing.T) {
+	w, files, finished := rollingWriterForTest(RollingPolicy{MaxSize: 2000})
+	for i := 0; i < 100; i++ {
+		assert(t, w.Write(&primitive{LongField: int64(i), StringField: randomString(40)}), nil)
+	}
+	assert(t, w.Close(), nil)
+	if len(*files) < 3 {
+		t.Fatalf("Expected files to be rolled by size, got %d files", len(*files))
+	}
+	total := int64(0)
+	for _, file := range *finished {
+		total += file.Records
+		// a file may exceed the limit by a single datum and its block framing
+		if file.Size > 2200 {
+			t.Fatalf("File %s too large: %d bytes", file.Name, file.Size)
+		}
+	}
+	assert(t, total, int64(100))
+
+	now := time.Now()
+	w, files, finished = rollingWriterForTest(RollingPolicy{MaxAge: time.Minute})
+	w.now = func() time.Time { return now }
+	for i := 0; i < 10; i++ {
+		assert(t, w.Write(&primitive{LongField: int64(i)}), nil)
+		now = now.Add(15 * time.Second)
+	}
+	assert(t, len(*finished), 2)
+	assert(t, w.Roll(), nil)
+	assert(t, w.Roll(), nil)
+	assert(t, len(*files), 3)
+	assert(t, []int64{(*finished)[0].Records, (*finished)[1].Records, (*finished)[2].Records}, []int64{4, 4, 2})
+	assert(t, w.Close(), nil)
+	assert(t, len(*finished), 3)
+
+	// an idle writer finishes its file on Flush once it is too old
+	w, files, finished = rollingWriterForTest(RollingPolicy{MaxAge: time.Minute})
+	w.now = func() time.Time { return now }
+	assert(t, w.Flush(), nil)
+	assert(t, w.Write(&primitive{LongField: 1}), nil)
+	now = now.Add(30 * time.Second)
+	assert(t, w.Flush(), nil)
+	assert(t, len(*finished), 0)
+	if (*files)[0].Len() == 0 {
+		t.Fatal("Expected the block to be flushed")
+	}
+	now = now.Add(30 * time.Second)
+	assert(t, w.Flush(), nil)
+	assert(t, len(*finished), 1)
+	assert(t, readMemoryFile(t, (*files)[0]), []int64{1})
+	assert(t, w.Close(), nil)
+	assert(t, len(*files), 1)
+}
+
+func TestNumberedFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rolling")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var finished []string
+	w := NewRollingDataFileWriter(NumberedFiles(filepath.Join(dir, "part-%03d.avro")), MustParseSchema(primitiveSchemaRaw),
+		NewSpecificDatumWriter(), RollingPolicy{MaxRecords: 5})
+	w.SetFinishedHandler(func(file FinishedFile) {
+		finished = append(finished, filepath.Base(file.Name))
+	})
+	for i := 0; i < 12; i++ {
+		assert(t, w.Write(&primitive{LongField: int64(i)}), nil)
+	}
+	assert(t, w.Close(), nil)
+	assert(t, finished, []string{"part-000.avro", "part-001.avro", "part-002.avro"})
+
+	dfr, err := NewDataFileReader(filepath.Join(dir, "part-002.avro"), NewSpecificDatumReader())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var p primitive
+	ok, err := dfr.Next(&p)
+	assert(t, ok, true)
+	assert(t, err, nil)
+	assert(t, p.LongField, int64(10))
+}
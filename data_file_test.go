@@ -2,6 +2,10 @@ package avro
 
 import (
 	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
 	"testing"
 )
 
@@ -59,3 +63,687 @@ func TestDataFileWriter(t *testing.T) {
 	assert(t, err, nil)
 	assert(t, p.LongField, int64(1))
 }
+
+func TestDataFileDeflateCodec(t *testing.T) {
+	schema := MustParseSchema(primitiveSchemaRaw)
+	buf := &bytes.Buffer{}
+	dfw, err := NewDataFileWriter(buf, schema, NewSpecificDatumWriter())
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert(t, dfw.SetCodec(DeflateCodec), nil)
+	assert(t, dfw.SetCompressionLevel(9), nil)
+
+	for i := 0; i < 100; i++ {
+		p := primitive{
+			LongField:   int64(i),
+			StringField: "some compressible string",
+		}
+		if err = dfw.Write(&p); err != nil {
+			t.Fatal(err)
+		}
+		if i%30 == 0 {
+			if err = dfw.Flush(); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	assert(t, dfw.SetCodec(NullCodec), HeaderAlreadyWritten)
+	if err = dfw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	dfr, err := newDataFileReaderBytes(buf.Bytes(), NewSpecificDatumReader())
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert(t, string(dfr.header.Meta[codecKey]), DeflateCodec)
+	for i := 0; i < 100; i++ {
+		var p primitive
+		ok, err := dfr.Next(&p)
+		assert(t, err, nil)
+		assert(t, ok, true)
+		assert(t, p.LongField, int64(i))
+		assert(t, p.StringField, "some compressible string")
+	}
+	ok, err := dfr.Next(&primitive{})
+	assert(t, ok, false)
+	assert(t, err, nil)
+}
+
+func TestDataFileUnknownCodec(t *testing.T) {
+	dfw, err := NewDataFileWriter(&bytes.Buffer{}, MustParseSchema(primitiveSchemaRaw), NewSpecificDatumWriter())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = dfw.SetCodec("lzma"); err == nil {
+		t.Fatal("Expected an error for unsupported codec")
+	}
+	assert(t, dfw.SetCodec(DeflateCodec), nil)
+	if err = dfw.SetCompressionLevel(42); err == nil {
+		t.Fatal("Expected an error for invalid compression level")
+	}
+
+	buf := &bytes.Buffer{}
+	enc := NewBinaryEncoder(buf)
+	headerWriter := NewSpecificDatumWriter()
+	headerWriter.SetSchema(objHeaderSchema)
+	err = headerWriter.Write(&objFileHeader{
+		Magic: magic,
+		Meta: map[string][]byte{
+			schemaKey: []byte(primitiveSchemaRaw),
+			codecKey:  []byte("lzma"),
+		},
+		Sync: []byte("1234567890abcdef"),
+	}, enc)
+	assert(t, err, nil)
+	enc.WriteLong(0)
+	enc.WriteLong(0)
+	enc.WriteRaw([]byte("1234567890abcdef"))
+
+	dfr, err := newDataFileReaderBytes(buf.Bytes(), NewSpecificDatumReader())
+	assert(t, err, nil)
+	_, err = dfr.Next(&primitive{})
+	assert(t, err.Error(), "Unsupported codec: lzma")
+}
+
+func writeTestDataFile(t *testing.T, count int, flushEvery int) []byte {
+	buf := &bytes.Buffer{}
+	dfw, err := NewDataFileWriter(buf, MustParseSchema(primitiveSchemaRaw), NewSpecificDatumWriter())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < count; i++ {
+		if err = dfw.Write(&primitive{LongField: int64(i), StringField: randomString(10)}); err != nil {
+			t.Fatal(err)
+		}
+		if (i+1)%flushEvery == 0 {
+			if err = dfw.Flush(); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if err = dfw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestDataFileStreamReader(t *testing.T) {
+	encoded := writeTestDataFile(t, 1000, 100)
+
+	// hide everything but io.Reader from the DataFileReader
+	stream := struct{ io.Reader }{bytes.NewReader(encoded)}
+	dfr, err := NewDataFileStreamReader(stream, NewSpecificDatumReader())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 1000; i++ {
+		var p primitive
+		ok, err := dfr.Next(&p)
+		assert(t, err, nil)
+		assert(t, ok, true)
+		assert(t, p.LongField, int64(i))
+	}
+	ok, err := dfr.Next(&primitive{})
+	assert(t, ok, false)
+	assert(t, err, nil)
+
+	if err = dfr.Seek(0); err == nil {
+		t.Fatal("Expected an error seeking backwards in a stream")
+	}
+
+	_, err = NewDataFileStreamReader(bytes.NewReader([]byte("not avro")), NewSpecificDatumReader())
+	assert(t, err, NotAvroFile)
+
+	// a metadata key claiming to be huge must fail at the end of file instead of being allocated
+	header := &bytes.Buffer{}
+	header.Write(magic)
+	enc := NewBinaryEncoder(header)
+	enc.WriteLong(1)
+	enc.WriteLong(1 << 60)
+	header.WriteString("avro.schema")
+	_, err = NewDataFileStreamReader(header, NewSpecificDatumReader())
+	assert(t, err, EOF)
+
+	_, err = NewDataFileStreamReader(bytes.NewReader(encoded[:len(magic)+10]), NewSpecificDatumReader())
+	assert(t, err, EOF)
+
+	// a failing stream is not the end of file, also at the end of a block or in recovery mode
+	boundary := syncPositions(encoded, dfr.header.Sync)[3] + syncSize
+	for _, c := range []struct {
+		end      int
+		recovery bool
+	}{{len(encoded) / 2, false}, {boundary, false}, {len(encoded) / 2, true}} {
+		failure := errors.New("connection reset")
+		failing := io.MultiReader(bytes.NewReader(encoded[:c.end]), &failingReader{failure})
+		if dfr, err = NewDataFileStreamReader(failing, NewSpecificDatumReader()); err != nil {
+			t.Fatal(err)
+		}
+		if c.recovery {
+			dfr.SetRecoveryHandler(func(int64, error) { t.Fatal("Expected no recovery from a failing stream") })
+		}
+		for err == nil {
+			ok, err = dfr.Next(&primitive{})
+			if err == nil && !ok {
+				t.Fatal("Expected the error of the failing stream")
+			}
+		}
+		assert(t, err, failure)
+	}
+	failure := errors.New("connection reset")
+	_, err = NewDataFileStreamReader(&failingReader{failure}, NewSpecificDatumReader())
+	assert(t, err, failure)
+}
+
+type failingReader struct {
+	err error
+}
+
+func (r *failingReader) Read([]byte) (int, error) {
+	return 0, r.err
+}
+
+func TestDataFileReaderAt(t *testing.T) {
+	file, err := os.Open("test/primitives.avro")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dfr, err := NewDataFileReaderAt(file, info.Size(), NewSpecificDatumReader())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// remember where the first block starts to read it again later
+	firstBlock := dfr.input.pos
+	var first, again primitive
+	ok, err := dfr.Next(&first)
+	assert(t, err, nil)
+	assert(t, ok, true)
+	assert(t, first.StringField, primitiveString)
+
+	assert(t, dfr.Seek(firstBlock), nil)
+	ok, err = dfr.Next(&again)
+	assert(t, err, nil)
+	assert(t, ok, true)
+	assert(t, again, first)
+}
+
+func TestDataFileSync(t *testing.T) {
+	buf := &bytes.Buffer{}
+	dfw, err := NewDataFileWriter(buf, MustParseSchema(primitiveSchemaRaw), NewSpecificDatumWriter())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var positions []int64
+	for i := 0; i < 50; i++ {
+		if i%10 == 0 {
+			pos, err := dfw.Sync()
+			assert(t, err, nil)
+			assert(t, pos, int64(buf.Len()))
+			positions = append(positions, pos)
+		}
+		assert(t, dfw.Write(&primitive{LongField: int64(i)}), nil)
+	}
+	assert(t, dfw.Close(), nil)
+
+	other, err := NewDataFileWriter(&bytes.Buffer{}, MustParseSchema(primitiveSchemaRaw), NewSpecificDatumWriter())
+	assert(t, err, nil)
+	if bytes.Equal(dfw.sync, other.sync) {
+		t.Fatal("Sync markers should be random")
+	}
+
+	dfr, err := newDataFileReaderBytes(buf.Bytes(), NewSpecificDatumReader())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := len(positions) - 1; i >= 0; i-- {
+		var p primitive
+		assert(t, dfr.Seek(positions[i]), nil)
+		ok, err := dfr.Next(&p)
+		assert(t, err, nil)
+		assert(t, ok, true)
+		assert(t, p.LongField, int64(i*10))
+
+		// syncing anywhere inside the previous block ends up at the same position
+		if i > 0 {
+			assert(t, dfr.SyncTo(positions[i-1]+1), nil)
+			ok, err = dfr.Next(&p)
+			assert(t, err, nil)
+			assert(t, ok, true)
+			assert(t, p.LongField, int64(i*10))
+		}
+	}
+
+	// syncing to the start of the file finds the first block
+	var p primitive
+	assert(t, dfr.SyncTo(0), nil)
+	_, err = dfr.Next(&p)
+	assert(t, err, nil)
+	assert(t, p.LongField, int64(0))
+
+	// there is no block after the last one
+	assert(t, dfr.SyncTo(int64(buf.Len()-syncSize)), nil)
+	ok, err := dfr.Next(&p)
+	assert(t, ok, false)
+	assert(t, err, nil)
+}
+
+func TestDataFileWriterAutoFlush(t *testing.T) {
+	buf := &bytes.Buffer{}
+	dfw, err := NewDataFileWriter(buf, MustParseSchema(primitiveSchemaRaw), NewSpecificDatumWriter())
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert(t, dfw.syncInterval, DefaultSyncInterval)
+	if err = dfw.SetSyncInterval(-1); err == nil {
+		t.Fatal("Expected an error for negative sync interval")
+	}
+	assert(t, dfw.SetSyncInterval(0), nil)
+	assert(t, dfw.SetBlockRecordLimit(7), nil)
+
+	for i := 0; i < 20; i++ {
+		assert(t, dfw.Write(&primitive{LongField: int64(i)}), nil)
+		assert(t, dfw.blockCount, int64((i+1)%7))
+	}
+
+	// a failed write leaves the current block untouched
+	assert(t, dfw.SetBlockRecordLimit(0), nil)
+	size := dfw.blockBuf.Len()
+	invalid := &struct {
+		BooleanField bool
+		IntField     string
+	}{true, "oops"}
+	if err = dfw.Write(invalid); err == nil {
+		t.Fatal("Expected an error writing invalid datum")
+	}
+	assert(t, dfw.blockCount, int64(6))
+	assert(t, dfw.blockBuf.Len(), size)
+
+	// flush by size
+	assert(t, dfw.SetSyncInterval(100), nil)
+	for i := 20; i < 100; i++ {
+		assert(t, dfw.Write(&primitive{LongField: int64(i)}), nil)
+		if dfw.blockBuf.Len() >= 100 {
+			t.Fatalf("Block of %d bytes was not flushed", dfw.blockBuf.Len())
+		}
+	}
+	assert(t, dfw.Close(), nil)
+
+	dfr, err := newDataFileReaderBytes(buf.Bytes(), NewSpecificDatumReader())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 100; i++ {
+		var p primitive
+		ok, err := dfr.Next(&p)
+		assert(t, err, nil)
+		assert(t, ok, true)
+		assert(t, p.LongField, int64(i))
+	}
+}
+
+func TestDataFileAppend(t *testing.T) {
+	file, err := ioutil.TempFile("", "append")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(file.Name())
+
+	schema := MustParseSchema(primitiveSchemaRaw)
+	dfw, err := NewDataFileWriter(file, schema, NewSpecificDatumWriter())
+	assert(t, err, nil)
+	assert(t, dfw.SetCodec(DeflateCodec), nil)
+	for i := 0; i < 10; i++ {
+		assert(t, dfw.Write(&primitive{LongField: int64(i)}), nil)
+	}
+	assert(t, dfw.Close(), nil)
+	assert(t, file.Close(), nil)
+
+	_, err = OpenDataFileWriterForAppend(file.Name(), MustParseSchema(`{"type":"record","name":"Other","fields":[]}`), NewSpecificDatumWriter())
+	if err == nil {
+		t.Fatal("Expected an error appending with a different schema")
+	}
+
+	for _, appendSchema := range []Schema{nil, MustParseSchema(primitiveSchemaRaw)} {
+		dfw, err = OpenDataFileWriterForAppend(file.Name(), appendSchema, NewSpecificDatumWriter())
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert(t, dfw.SetCodec(NullCodec), HeaderAlreadyWritten)
+		for i := 0; i < 10; i++ {
+			assert(t, dfw.Write(&primitive{LongField: int64(i)}), nil)
+		}
+		assert(t, dfw.Close(), nil)
+	}
+
+	dfr, err := NewDataFileReader(file.Name(), NewSpecificDatumReader())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 30; i++ {
+		var p primitive
+		ok, err := dfr.Next(&p)
+		assert(t, err, nil)
+		assert(t, ok, true)
+		assert(t, p.LongField, int64(i%10))
+	}
+	ok, err := dfr.Next(&primitive{})
+	assert(t, ok, false)
+	assert(t, err, nil)
+
+	assert(t, ioutil.WriteFile(file.Name(), []byte("not avro"), 0644), nil)
+	_, err = OpenDataFileWriterForAppend(file.Name(), nil, NewSpecificDatumWriter())
+	assert(t, err, NotAvroFile)
+}
+
+func TestDataFileMeta(t *testing.T) {
+	buf := &bytes.Buffer{}
+	schema := MustParseSchema(primitiveSchemaRaw)
+	dfw, err := NewDataFileWriter(buf, schema, NewSpecificDatumWriter())
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert(t, dfw.SetMeta("producer", []byte("test 1.0")), nil)
+	assert(t, dfw.SetMeta("lineage", []byte("42")), nil)
+	if err = dfw.SetMeta("avro.codec", []byte("deflate")); err == nil {
+		t.Fatal("Expected an error setting reserved metadata")
+	}
+	assert(t, dfw.Write(&primitive{}), nil)
+	assert(t, dfw.SetMeta("late", []byte("value")), HeaderAlreadyWritten)
+	assert(t, dfw.Close(), nil)
+
+	dfr, err := newDataFileReaderBytes(buf.Bytes(), NewSpecificDatumReader())
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert(t, dfr.MetaKeys(), []string{"avro.codec", "avro.schema", "lineage", "producer"})
+	assert(t, dfr.GetMeta("producer"), []byte("test 1.0"))
+	assert(t, dfr.GetMeta("lineage"), []byte("42"))
+	assert(t, dfr.GetMeta(codecKey), []byte(NullCodec))
+	assert(t, dfr.GetMeta("late"), []byte(nil))
+	assert(t, dfr.Schema().String(), schema.String())
+}
+
+func TestDataFileReaderSchema(t *testing.T) {
+	encoded := writeTestDataFile(t, 100, 30)
+
+	type projection struct {
+		StringField string
+		LongField   float64
+		Version     int32
+	}
+	readerSchema := MustParseSchema(`{"type": "record", "name": "Primitive", "fields": [
+		{"name": "stringField", "type": "string"},
+		{"name": "longField", "type": "double"},
+		{"name": "version", "type": "int", "default": 2}
+	]}`)
+
+	dfr, err := newDataFileReaderBytes(encoded, NewSpecificDatumReader())
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert(t, dfr.SetReaderSchema(readerSchema), nil)
+	for i := 0; i < 100; i++ {
+		var p projection
+		ok, err := dfr.Next(&p)
+		assert(t, err, nil)
+		assert(t, ok, true)
+		assert(t, p.LongField, float64(i))
+		assert(t, len(p.StringField), 10)
+		assert(t, p.Version, int32(2))
+	}
+	ok, err := dfr.Next(&projection{})
+	assert(t, ok, false)
+	assert(t, err, nil)
+
+	incompatible := MustParseSchema(`{"type": "record", "name": "Primitive", "fields": [
+		{"name": "longField", "type": "int"}
+	]}`)
+	if err = dfr.SetReaderSchema(incompatible); err == nil {
+		t.Fatal("Expected an error setting an incompatible reader schema")
+	}
+}
+
+// syncPositions returns the positions right after every sync marker of an encoded file, the first one being the
+// start of the first block
+func syncPositions(encoded []byte, sync []byte) []int {
+	var positions []int
+	for i := bytes.Index(encoded, sync); i >= 0; {
+		positions = append(positions, i+len(sync))
+		next := bytes.Index(encoded[i+len(sync):], sync)
+		if next < 0 {
+			break
+		}
+		i += len(sync) + next
+	}
+	return positions
+}
+
+func TestDataFileRecovery(t *testing.T) {
+	encoded := writeTestDataFile(t, 100, 10)
+	dfr, err := newDataFileReaderBytes(encoded, NewSpecificDatumReader())
+	if err != nil {
+		t.Fatal(err)
+	}
+	blocks := syncPositions(encoded, dfr.header.Sync)
+
+	type report struct {
+		offset int64
+		err    error
+	}
+	readAll := func(dfr *DataFileReader) ([]int64, []report) {
+		var values []int64
+		var reports []report
+		dfr.SetRecoveryHandler(func(offset int64, err error) {
+			reports = append(reports, report{offset, err})
+		})
+		for {
+			var p primitive
+			ok, err := dfr.Next(&p)
+			assert(t, err, nil)
+			if !ok {
+				return values, reports
+			}
+			values = append(values, p.LongField)
+		}
+	}
+
+	// a broken sync marker loses its block and the next one, which is found by the following marker
+	corrupted := append([]byte(nil), encoded...)
+	corrupted[blocks[4]-1] ^= 0xFF
+	for _, stream := range []bool{false, true} {
+		if stream {
+			dfr, err = NewDataFileStreamReader(struct{ io.Reader }{bytes.NewReader(corrupted)}, NewSpecificDatumReader())
+		} else {
+			dfr, err = newDataFileReaderBytes(corrupted, NewSpecificDatumReader())
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		values, reports := readAll(dfr)
+		assert(t, len(values), 80)
+		assert(t, values[29], int64(29))
+		assert(t, values[30], int64(50))
+		assert(t, reports, []report{{int64(blocks[3]), InvalidSync}})
+	}
+
+	// a truncated file keeps all complete blocks
+	dfr, err = newDataFileReaderBytes(encoded[:blocks[7]+20], NewSpecificDatumReader())
+	if err != nil {
+		t.Fatal(err)
+	}
+	values, reports := readAll(dfr)
+	assert(t, len(values), 70)
+	assert(t, reports, []report{{int64(blocks[7]), EOF}})
+
+	// a block with more values than it contains skips the rest of the block
+	corrupted = append([]byte(nil), encoded...)
+	assert(t, corrupted[blocks[2]], byte(20))
+	corrupted[blocks[2]] = 22
+	dfr, err = newDataFileReaderBytes(corrupted, NewSpecificDatumReader())
+	if err != nil {
+		t.Fatal(err)
+	}
+	values, reports = readAll(dfr)
+	assert(t, len(values), 100)
+	assert(t, len(reports), 1)
+	assert(t, reports[0].offset, int64(blocks[2]))
+
+	// without a handler, the error is returned
+	dfr, err = newDataFileReaderBytes(corrupted, NewSpecificDatumReader())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 30; i++ {
+		ok, err := dfr.Next(&primitive{})
+		assert(t, ok, true)
+		assert(t, err, nil)
+	}
+	if _, err = dfr.Next(&primitive{}); err == nil {
+		t.Fatal("Expected an error reading a corrupted block")
+	}
+}
+
+func TestDataFileRawBlocks(t *testing.T) {
+	plain := writeTestDataFile(t, 100, 30)
+
+	buf := &bytes.Buffer{}
+	dfw, err := NewDataFileWriter(buf, MustParseSchema(primitiveSchemaRaw), NewSpecificDatumWriter())
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert(t, dfw.SetCodec(DeflateCodec), nil)
+	for i := 100; i < 150; i++ {
+		assert(t, dfw.Write(&primitive{LongField: int64(i)}), nil)
+	}
+	assert(t, dfw.Close(), nil)
+	deflated := buf.Bytes()
+
+	// concatenate both files into a snappy file, a written datum in between
+	output := &bytes.Buffer{}
+	dfw, err = NewDataFileWriter(output, MustParseSchema(primitiveSchemaRaw), NewSpecificDatumWriter())
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert(t, dfw.SetCodec(SnappyCodec), nil)
+	blocks := 0
+	for i, encoded := range [][]byte{plain, deflated} {
+		if i == 1 {
+			assert(t, dfw.Write(&primitive{LongField: -1}), nil)
+		}
+		dfr, err := newDataFileReaderBytes(encoded, NewSpecificDatumReader())
+		if err != nil {
+			t.Fatal(err)
+		}
+		var block RawBlock
+		for {
+			ok, err := dfr.NextRawBlock(&block)
+			assert(t, err, nil)
+			if !ok {
+				break
+			}
+			assert(t, block.Codec, []string{NullCodec, DeflateCodec}[i])
+			assert(t, dfw.AppendRawBlock(&block), nil)
+			blocks++
+		}
+	}
+	assert(t, blocks, 5)
+	assert(t, dfw.Close(), nil)
+
+	dfr, err := newDataFileReaderBytes(output.Bytes(), NewSpecificDatumReader())
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert(t, dfr.GetMeta(codecKey), []byte(SnappyCodec))
+	var values []int64
+	for {
+		var p primitive
+		ok, err := dfr.Next(&p)
+		assert(t, err, nil)
+		if !ok {
+			break
+		}
+		values = append(values, p.LongField)
+	}
+	assert(t, len(values), 151)
+	assert(t, values[99], int64(99))
+	assert(t, values[100], int64(-1))
+	assert(t, values[150], int64(149))
+
+	// blocks of other schemas are rejected
+	dfr, err = newDataFileReaderBytes(plain, NewSpecificDatumReader())
+	if err != nil {
+		t.Fatal(err)
+	}
+	dfw, err = NewDataFileWriter(&bytes.Buffer{}, MustParseSchema(`{"type": "record", "name": "Other", "fields": []}`), NewSpecificDatumWriter())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var block RawBlock
+	ok, err := dfr.NextRawBlock(&block)
+	assert(t, ok, true)
+	assert(t, err, nil)
+	if err = dfw.AppendRawBlock(&block); err == nil {
+		t.Fatal("Expected an error appending a block of another schema")
+	}
+}
+
+func TestDataFileSplits(t *testing.T) {
+	encoded := writeTestDataFile(t, 1000, 17)
+	size := int64(len(encoded))
+
+	for _, splits := range []int64{1, 2, 7, 50, size / 10} {
+		var values []int64
+		splitSize := size/splits + 1
+		for start := int64(0); start < size; start += splitSize {
+			dfr, err := NewDataFileReaderAt(bytes.NewReader(encoded), size, NewSpecificDatumReader())
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert(t, dfr.SetSplit(start, start+splitSize), nil)
+			for {
+				var p primitive
+				ok, err := dfr.Next(&p)
+				assert(t, err, nil)
+				if !ok {
+					break
+				}
+				values = append(values, p.LongField)
+			}
+		}
+
+		assert(t, len(values), 1000)
+		for i, value := range values {
+			assert(t, value, int64(i))
+		}
+	}
+
+	// the first split of a stream needs no seeking
+	dfr, err := NewDataFileStreamReader(struct{ io.Reader }{bytes.NewReader(encoded)}, NewSpecificDatumReader())
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert(t, dfr.SetSplit(0, size/2), nil)
+	count := 0
+	for {
+		ok, err := dfr.Next(&primitive{})
+		assert(t, err, nil)
+		if !ok {
+			break
+		}
+		count++
+	}
+	if count == 0 || count == 1000 {
+		t.Fatalf("Expected about half of the values in the first split, got %d", count)
+	}
+
+	if err = dfr.SetSplit(10, 5); err == nil {
+		t.Fatal("Expected an error for an invalid split")
+	}
+}
@@ -81,7 +81,8 @@ func (enum *GenericEnum) Set(symbol string) {
 // Each value passed to Read is expected to be a pointer.
 type SpecificDatumReader struct {
 	sDatumReader
-	schema Schema
+	schema     Schema
+	resolution datumResolution
 }
 
 // NewSpecificDatumReader creates a new SpecificDatumReader.
@@ -93,6 +94,17 @@ func NewSpecificDatumReader() *SpecificDatumReader {
 // Note that it must be called before calling Read.
 func (reader *SpecificDatumReader) SetSchema(schema Schema) {
 	reader.schema = schema
+	reader.resolution.compile(schema)
+}
+
+// SetWriterSchema tells this SpecificDatumReader that data is written with the given schema, which is resolved with
+// the schema set with SetSchema according to the schema resolution rules: fields are matched by name, fields unknown
+// to the writer's schema are filled from their defaults, numbers and strings are promoted and so on.
+// Passing nil reads data as written with the schema set with SetSchema again.
+// Returns an error if the schemas cannot be resolved, which is also returned by Read if SetSchema is called later.
+func (reader *SpecificDatumReader) SetWriterSchema(schema Schema) error {
+	reader.resolution.writer = schema
+	return reader.resolution.compile(reader.schema)
 }
 
 // Read reads a single structured entry using this SpecificDatumReader.
@@ -103,6 +115,10 @@ func (reader *SpecificDatumReader) SetSchema(schema Schema) {
 // your struct field as follows: SomeValue int32 `avro:"some_field"`).
 // May return an error indicating a read failure.
 func (reader *SpecificDatumReader) Read(v interface{}, dec Decoder) error {
+	dec, err := reader.resolution.decoder(dec)
+	if err != nil {
+		return err
+	}
 	if reader, ok := v.(Reader); ok {
 		return reader.Read(dec)
 	}
@@ -146,17 +162,25 @@ func (reader sDatumReader) readValue(field Schema, reflectField reflect.Value, d
 	case Boolean:
 		return reader.mapPrimitive(func() (interface{}, error) { return dec.ReadBoolean() })
 	case Int:
-		return reader.mapPrimitive(func() (interface{}, error) { return dec.ReadInt() })
+		value, err := reader.mapPrimitive(func() (interface{}, error) { return dec.ReadInt() })
+		if err != nil {
+			return value, err
+		}
+		return reader.mapTemporal(field, reflectField, value.Int(), value), nil
 	case Long:
-		return reader.mapPrimitive(func() (interface{}, error) { return dec.ReadLong() })
+		value, err := reader.mapPrimitive(func() (interface{}, error) { return dec.ReadLong() })
+		if err != nil {
+			return value, err
+		}
+		return reader.mapTemporal(field, reflectField, value.Int(), value), nil
 	case Float:
 		return reader.mapPrimitive(func() (interface{}, error) { return dec.ReadFloat() })
 	case Double:
 		return reader.mapPrimitive(func() (interface{}, error) { return dec.ReadDouble() })
 	case Bytes:
-		return reader.mapPrimitive(func() (interface{}, error) { return dec.ReadBytes() })
+		return reader.mapBytes(field, reflectField, dec)
 	case String:
-		return reader.mapPrimitive(func() (interface{}, error) { return dec.ReadString() })
+		return reader.mapString(field, reflectField, dec)
 	case Array:
 		return reader.mapArray(field, reflectField, dec)
 	case Enum:
@@ -166,11 +190,13 @@ func (reader sDatumReader) readValue(field Schema, reflectField reflect.Value, d
 	case Union:
 		return reader.mapUnion(field, reflectField, dec)
 	case Fixed:
-		return reader.mapFixed(field, dec)
+		return reader.mapFixed(field, reflectField, dec)
 	case Record:
 		return reader.mapRecord(field, reflectField, dec)
 	case Recursive:
 		return reader.mapRecord(field.(*RecursiveSchema).Actual, reflectField, dec)
+	case Alias:
+		return reader.readValue(field.(*AliasSchema).RefSchema, reflectField, dec)
 	}
 
 	return reflect.ValueOf(nil), fmt.Errorf("Unknown field type: %d", field.Type())
@@ -192,6 +218,17 @@ func (reader sDatumReader) mapPrimitive(readerFunc func() (interface{}, error))
 	return reflect.ValueOf(value), nil
 }
 
+// mapTemporal converts the given underlying value of a schema with a TemporalType if it is read into a time.Time or
+// time.Duration.
+func (reader sDatumReader) mapTemporal(field Schema, reflectField reflect.Value, n int64, value reflect.Value) reflect.Value {
+	temporalType, ok := GetLogicalType(field).(TemporalType)
+	if !ok || !reflectField.IsValid() || reflectField.Type() != temporalType.goType() {
+		return value
+	}
+
+	return reflect.ValueOf(temporalType.decode(n))
+}
+
 func (reader sDatumReader) mapArray(field Schema, reflectField reflect.Value, dec Decoder) (reflect.Value, error) {
 	arrayLength, err := dec.ReadArrayStart()
 	if err != nil {
@@ -311,12 +348,53 @@ func (reader sDatumReader) mapUnion(field Schema, reflectField reflect.Value, de
 	return reader.readValue(union, reflectField, dec)
 }
 
-func (reader sDatumReader) mapFixed(field Schema, dec Decoder) (reflect.Value, error) {
+func (reader sDatumReader) mapBytes(field Schema, reflectField reflect.Value, dec Decoder) (reflect.Value, error) {
+	bytes, err := dec.ReadBytes()
+	if err != nil {
+		return reflect.ValueOf(bytes), err
+	}
+	return reader.mapLogicalBytes(field, reflectField, bytes), nil
+}
+
+func (reader sDatumReader) mapFixed(field Schema, reflectField reflect.Value, dec Decoder) (reflect.Value, error) {
 	fixed := make([]byte, field.(*FixedSchema).Size)
 	if err := dec.ReadFixed(fixed); err != nil {
 		return reflect.ValueOf(fixed), err
 	}
-	return reflect.ValueOf(fixed), nil
+	return reader.mapLogicalBytes(field, reflectField, fixed), nil
+}
+
+// mapLogicalBytes converts bytes of a schema with a logical type unless they are read into a []byte.
+func (reader sDatumReader) mapLogicalBytes(field Schema, reflectField reflect.Value, bytes []byte) reflect.Value {
+	if reflectField.IsValid() && reflectField.Type() == bytesType {
+		return reflect.ValueOf(bytes)
+	}
+
+	switch logicalType := GetLogicalType(field).(type) {
+	case *DecimalType:
+		decimal := logicalType.decode(bytes)
+		if reflectField.IsValid() && reflectField.Type() == ratType {
+			return reflect.ValueOf(decimal).Elem()
+		}
+		return reflect.ValueOf(decimal)
+	case *DurationType:
+		return reflect.ValueOf(logicalType.decode(bytes))
+	}
+	return reflect.ValueOf(bytes)
+}
+
+// mapString reads a string, converting it to a UUID if it is a uuid read into a UUID.
+func (reader sDatumReader) mapString(field Schema, reflectField reflect.Value, dec Decoder) (reflect.Value, error) {
+	str, err := dec.ReadString()
+	if err != nil {
+		return reflect.ValueOf(str), err
+	}
+	if !isUUID(field) || !reflectField.IsValid() || reflectField.Type() != uuidType {
+		return reflect.ValueOf(str), nil
+	}
+
+	uuid, err := ParseUUID(str)
+	return reflect.ValueOf(uuid), err
 }
 
 func (reader sDatumReader) mapRecord(field Schema, reflectField reflect.Value, dec Decoder) (reflect.Value, error) {
@@ -356,7 +434,9 @@ func (this sDatumReader) fillRecord(field Schema, record reflect.Value, dec Deco
 		recordSchema := field.(*RecordSchema)
 		//ri := record.Interface()
 		for i := 0; i < len(recordSchema.Fields); i++ {
-			this.findAndSet(record, recordSchema.Fields[i], dec)
+			if err := this.findAndSet(record, recordSchema.Fields[i], dec); err != nil {
+				return err
+			}
 		}
 	}
 	return nil
@@ -367,7 +447,9 @@ func (this sDatumReader) fillRecord(field Schema, record reflect.Value, dec Deco
 // and any values, GenericEnums) with data.
 // Each value passed to Read is expected to be a pointer.
 type GenericDatumReader struct {
-	schema Schema
+	schema     Schema
+	resolution datumResolution
+	timeValues bool
 }
 
 // NewGenericDatumReader creates a new GenericDatumReader.
@@ -379,6 +461,23 @@ func NewGenericDatumReader() *GenericDatumReader {
 // Note that it must be called before calling Read.
 func (reader *GenericDatumReader) SetSchema(schema Schema) {
 	reader.schema = schema
+	reader.resolution.compile(schema)
+}
+
+// SetWriterSchema tells this GenericDatumReader that data is written with the given schema, which is resolved with
+// the schema set with SetSchema according to the schema resolution rules, see SpecificDatumReader.SetWriterSchema.
+// Passing nil reads data as written with the schema set with SetSchema again.
+// Returns an error if the schemas cannot be resolved, which is also returned by Read if SetSchema is called later.
+func (reader *GenericDatumReader) SetWriterSchema(schema Schema) error {
+	reader.resolution.writer = schema
+	return reader.resolution.compile(reader.schema)
+}
+
+// SetTimeValues sets whether this GenericDatumReader returns time.Time values for the date and timestamp logical types
+// and time.Duration values for the time logical types rather than their underlying int32 and int64 values.
+// Defaults to false.
+func (reader *GenericDatumReader) SetTimeValues(enabled bool) {
+	reader.timeValues = enabled
 }
 
 // Read reads a single entry using this GenericDatumReader.
@@ -393,6 +492,10 @@ func (reader *GenericDatumReader) Read(v interface{}, dec Decoder) error {
 	if reader.schema == nil {
 		return SchemaNotSet
 	}
+	dec, err := reader.resolution.decoder(dec)
+	if err != nil {
+		return err
+	}
 
 	//read the value
 	value, err := reader.readValue(reader.schema, dec)
@@ -439,15 +542,27 @@ func (reader *GenericDatumReader) readValue(field Schema, dec Decoder) (interfac
 	case Boolean:
 		return dec.ReadBoolean()
 	case Int:
-		return dec.ReadInt()
+		value, err := dec.ReadInt()
+		if err != nil {
+			return value, err
+		}
+		return reader.mapTemporal(field, int64(value), value), nil
 	case Long:
-		return dec.ReadLong()
+		value, err := dec.ReadLong()
+		if err != nil {
+			return value, err
+		}
+		return reader.mapTemporal(field, value, value), nil
 	case Float:
 		return dec.ReadFloat()
 	case Double:
 		return dec.ReadDouble()
 	case Bytes:
-		return dec.ReadBytes()
+		bytes, err := dec.ReadBytes()
+		if err != nil {
+			return nil, err
+		}
+		return reader.mapLogicalBytes(field, bytes), nil
 	case String:
 		return dec.ReadString()
 	case Array:
@@ -464,11 +579,21 @@ func (reader *GenericDatumReader) readValue(field Schema, dec Decoder) (interfac
 		return reader.mapRecord(field, dec)
 	case Recursive:
 		return reader.mapRecord(field.(*RecursiveSchema).Actual, dec)
+	case Alias:
+		return reader.readValue(field.(*AliasSchema).RefSchema, dec)
 	}
 
 	return nil, fmt.Errorf("Unknown field type: %d", field.Type())
 }
 
+// mapTemporal converts the given underlying value of a schema with a TemporalType if time values are enabled.
+func (reader *GenericDatumReader) mapTemporal(field Schema, n int64, value interface{}) interface{} {
+	if temporalType, ok := GetLogicalType(field).(TemporalType); ok && reader.timeValues {
+		return temporalType.decode(n)
+	}
+	return value
+}
+
 func (reader *GenericDatumReader) mapArray(field Schema, dec Decoder) ([]interface{}, error) {
 	arrayLength, err := dec.ReadArrayStart()
 	if err != nil {
@@ -572,12 +697,23 @@ func (reader *GenericDatumReader) mapUnion(field Schema, dec Decoder) (interface
 	return nil, UnionTypeOverflow
 }
 
-func (reader *GenericDatumReader) mapFixed(field Schema, dec Decoder) ([]byte, error) {
+func (reader *GenericDatumReader) mapFixed(field Schema, dec Decoder) (interface{}, error) {
 	fixed := make([]byte, field.(*FixedSchema).Size)
 	if err := dec.ReadFixed(fixed); err != nil {
 		return nil, err
 	}
-	return fixed, nil
+	return reader.mapLogicalBytes(field, fixed), nil
+}
+
+// mapLogicalBytes converts bytes of a schema with a logical type, i.e. decimals to *big.Rat and durations to Duration.
+func (reader *GenericDatumReader) mapLogicalBytes(field Schema, bytes []byte) interface{} {
+	switch logicalType := GetLogicalType(field).(type) {
+	case *DecimalType:
+		return logicalType.decode(bytes)
+	case *DurationType:
+		return logicalType.decode(bytes)
+	}
+	return bytes
 }
 
 func (reader *GenericDatumReader) mapRecord(field Schema, dec Decoder) (*GenericRecord, error) {
@@ -112,6 +112,11 @@ func (writer *SpecificDatumWriter) writeInt(v reflect.Value, enc Encoder, s Sche
 		return fmt.Errorf("Invalid int value: %v", v.Interface())
 	}
 
+	if n, ok := temporalValue(s, v); ok {
+		enc.WriteInt(int32(n))
+		return nil
+	}
+
 	enc.WriteInt(v.Interface().(int32))
 	return nil
 }
@@ -121,6 +126,11 @@ func (writer *SpecificDatumWriter) writeLong(v reflect.Value, enc Encoder, s Sch
 		return fmt.Errorf("Invalid long value: %v", v.Interface())
 	}
 
+	if n, ok := temporalValue(s, v); ok {
+		enc.WriteLong(n)
+		return nil
+	}
+
 	enc.WriteLong(v.Interface().(int64))
 	return nil
 }
@@ -148,6 +158,15 @@ func (writer *SpecificDatumWriter) writeBytes(v reflect.Value, enc Encoder, s Sc
 		return fmt.Errorf("Invalid bytes value: %v", v.Interface())
 	}
 
+	if decimal, ok := ratValue(v); ok {
+		b, err := GetLogicalType(s).(*DecimalType).encode(decimal, 0)
+		if err != nil {
+			return err
+		}
+		enc.WriteBytes(b)
+		return nil
+	}
+
 	enc.WriteBytes(v.Interface().([]byte))
 	return nil
 }
@@ -157,6 +176,12 @@ func (writer *SpecificDatumWriter) writeString(v reflect.Value, enc Encoder, s S
 		return fmt.Errorf("Invalid string value: %v", v.Interface())
 	}
 
+	if isUUID(s) {
+		str, _ := uuidString(v)
+		enc.WriteString(str)
+		return nil
+	}
+
 	enc.WriteString(v.Interface().(string))
 	return nil
 }
@@ -237,6 +262,19 @@ func (writer *SpecificDatumWriter) writeFixed(v reflect.Value, enc Encoder, s Sc
 		return fmt.Errorf("Invalid fixed value: %v", v.Interface())
 	}
 
+	if decimal, ok := ratValue(v); ok {
+		b, err := fs.LogicalType.(*DecimalType).encode(decimal, fs.Size)
+		if err != nil {
+			return err
+		}
+		enc.WriteRaw(b)
+		return nil
+	}
+	if duration, ok := durationValue(v); ok {
+		enc.WriteRaw(fs.LogicalType.(*DurationType).encode(duration))
+		return nil
+	}
+
 	// Write the raw bytes. The length is known by the schema
 	enc.WriteRaw(v.Interface().([]byte))
 	return nil
@@ -293,17 +331,17 @@ func (writer *GenericDatumWriter) write(v interface{}, enc Encoder, s Schema) er
 	case Boolean:
 		return writer.writeBoolean(v, enc)
 	case Int:
-		return writer.writeInt(v, enc)
+		return writer.writeInt(v, enc, s)
 	case Long:
-		return writer.writeLong(v, enc)
+		return writer.writeLong(v, enc, s)
 	case Float:
 		return writer.writeFloat(v, enc)
 	case Double:
 		return writer.writeDouble(v, enc)
 	case Bytes:
-		return writer.writeBytes(v, enc)
+		return writer.writeBytes(v, enc, s)
 	case String:
-		return writer.writeString(v, enc)
+		return writer.writeString(v, enc, s)
 	case Array:
 		return writer.writeArray(v, enc, s)
 	case Map:
@@ -336,23 +374,31 @@ func (writer *GenericDatumWriter) writeBoolean(v interface{}, enc Encoder) error
 	return nil
 }
 
-func (writer *GenericDatumWriter) writeInt(v interface{}, enc Encoder) error {
+func (writer *GenericDatumWriter) writeInt(v interface{}, enc Encoder, s Schema) error {
 	switch value := v.(type) {
 	case int32:
 		enc.WriteInt(value)
 	default:
-		return fmt.Errorf("%v is not an int32", v)
+		n, ok := temporalValue(s, reflect.ValueOf(v))
+		if !ok {
+			return fmt.Errorf("%v is not an int32", v)
+		}
+		enc.WriteInt(int32(n))
 	}
 
 	return nil
 }
 
-func (writer *GenericDatumWriter) writeLong(v interface{}, enc Encoder) error {
+func (writer *GenericDatumWriter) writeLong(v interface{}, enc Encoder, s Schema) error {
 	switch value := v.(type) {
 	case int64:
 		enc.WriteLong(value)
 	default:
-		return fmt.Errorf("%v is not an int64", v)
+		n, ok := temporalValue(s, reflect.ValueOf(v))
+		if !ok {
+			return fmt.Errorf("%v is not an int64", v)
+		}
+		enc.WriteLong(n)
 	}
 
 	return nil
@@ -380,18 +426,36 @@ func (writer *GenericDatumWriter) writeDouble(v interface{}, enc Encoder) error
 	return nil
 }
 
-func (writer *GenericDatumWriter) writeBytes(v interface{}, enc Encoder) error {
+func (writer *GenericDatumWriter) writeBytes(v interface{}, enc Encoder, s Schema) error {
 	switch value := v.(type) {
 	case []byte:
 		enc.WriteBytes(value)
 	default:
-		return fmt.Errorf("%v is not a []byte", v)
+		decimalType, ok := GetLogicalType(s).(*DecimalType)
+		decimal, isDecimal := ratValue(reflect.ValueOf(v))
+		if !ok || !isDecimal {
+			return fmt.Errorf("%v is not a []byte", v)
+		}
+		b, err := decimalType.encode(decimal, 0)
+		if err != nil {
+			return err
+		}
+		enc.WriteBytes(b)
 	}
 
 	return nil
 }
 
-func (writer *GenericDatumWriter) writeString(v interface{}, enc Encoder) error {
+func (writer *GenericDatumWriter) writeString(v interface{}, enc Encoder, s Schema) error {
+	if isUUID(s) {
+		str, ok := uuidString(reflect.ValueOf(v))
+		if !ok {
+			return fmt.Errorf("%v is not a uuid", v)
+		}
+		enc.WriteString(str)
+		return nil
+	}
+
 	switch value := v.(type) {
 	case string:
 		enc.WriteString(value)
@@ -440,7 +504,7 @@ func (writer *GenericDatumWriter) writeMap(v interface{}, enc Encoder, s Schema)
 	//TODO should probably write blocks of some length
 	enc.WriteMapStart(int64(rv.Len()))
 	for _, key := range rv.MapKeys() {
-		err := writer.writeString(key.Interface(), enc)
+		err := writer.writeString(key.Interface(), enc, &StringSchema{})
 		if err != nil {
 			return err
 		}
@@ -461,7 +525,7 @@ func (writer *GenericDatumWriter) writeEnum(v interface{}, enc Encoder, s Schema
 			rs := s.(*EnumSchema)
 			for i := range rs.Symbols {
 				if rs.Name == rs.Symbols[i] {
-					err := writer.writeInt(i, enc)
+					err := writer.writeInt(i, enc, s)
 					if err != nil {
 						return err
 					}
@@ -507,16 +571,26 @@ func (writer *GenericDatumWriter) isWritableAs(v interface{}, s Schema) bool {
 		_, ok = v.(bool)
 	case *IntSchema:
 		_, ok = v.(int32)
+		if !ok {
+			_, ok = temporalValue(s, reflect.ValueOf(v))
+		}
 	case *LongSchema:
 		_, ok = v.(int64)
+		if !ok {
+			_, ok = temporalValue(s, reflect.ValueOf(v))
+		}
 	case *FloatSchema:
 		_, ok = v.(float32)
 	case *DoubleSchema:
 		_, ok = v.(float64)
 	case *StringSchema:
 		_, ok = v.(string)
+		if isUUID(s) {
+			_, ok = uuidString(reflect.ValueOf(v))
+		}
 	case *BytesSchema:
 		_, ok = v.([]byte)
+		ok = ok || isDecimal(s, reflect.ValueOf(v))
 	case *ArraySchema:
 		{
 			kind := reflect.ValueOf(v).Kind()
@@ -540,7 +614,34 @@ func (writer *GenericDatumWriter) isWritableAs(v interface{}, s Schema) bool {
 }
 
 func (writer *GenericDatumWriter) writeFixed(v interface{}, enc Encoder, s Schema) error {
-	return writer.writeBytes(v, enc)
+	fs := s.(*FixedSchema)
+	switch value := v.(type) {
+	case []byte:
+		if len(value) != fs.Size {
+			return fmt.Errorf("%v does not have the size of fixed %s", v, fs.Name)
+		}
+		enc.WriteRaw(value)
+	default:
+		switch logicalType := fs.LogicalType.(type) {
+		case *DecimalType:
+			if decimal, ok := ratValue(reflect.ValueOf(v)); ok {
+				b, err := logicalType.encode(decimal, fs.Size)
+				if err != nil {
+					return err
+				}
+				enc.WriteRaw(b)
+				return nil
+			}
+		case *DurationType:
+			if duration, ok := durationValue(reflect.ValueOf(v)); ok {
+				enc.WriteRaw(logicalType.encode(duration))
+				return nil
+			}
+		}
+		return fmt.Errorf("%v is not a []byte", v)
+	}
+
+	return nil
 }
 
 func (writer *GenericDatumWriter) writeRecord(v interface{}, enc Encoder, s Schema) error {
@@ -183,6 +183,9 @@ func (bd *BinaryDecoder) ReadString() (string, error) {
 
 // ReadBoolean reads a boolean value. Returns a decoded value and an error if it occurs.
 func (bd *BinaryDecoder) ReadBoolean() (bool, error) {
+	if err := checkEOF(bd.buf, bd.pos, 1); err != nil {
+		return false, err
+	}
 	b := bd.buf[bd.pos] & 0xFF
 	bd.pos++
 	var err error
@@ -32,6 +32,9 @@ var NotAvroFile = errors.New("Not an Avro data file")
 // Happens when file header's sync and block's sync do not match - indicates corrupted data.
 var InvalidSync = errors.New("Invalid sync")
 
+// Happens when trying to configure a data file writer after its header has been written.
+var HeaderAlreadyWritten = errors.New("Data file header is already written")
+
 // Happens when trying to read next block without finishing the previous one.
 var BlockNotFinished = errors.New("Block read is unfinished")
 
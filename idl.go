@@ -0,0 +1,938 @@
+package avro
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Parsing of Avro IDL, which is translated to the JSON representation of schemas and protocols and parsed like it.
+// Spec: https://avro.apache.org/docs/current/idl-language/
+
+// IDLFile is a parsed Avro IDL file, which declares either a protocol or schemas only.
+type IDLFile struct {
+	// Protocol is the protocol declared by the file, nil if it declares schemas only.
+	Protocol *Protocol
+
+	// Schema is the main schema given by a schema declaration, nil if there is none.
+	Schema Schema
+
+	// Types are the named schemas declared and imported by the file in order.
+	Types []Schema
+}
+
+// ParseIDLFile parses a given IDL file and the files it imports, relative to its directory.
+// May return an error if the IDL is not parsable or a file does not exist.
+func ParseIDLFile(file string) (*IDLFile, error) {
+	return parseIDLFile(file, make(map[string]Schema), make(map[string]bool))
+}
+
+// ParseIDL parses a given IDL and the files it imports, relative to the working directory.
+// May return an error if the IDL is not parsable or has insufficient information about any type.
+func ParseIDL(rawIDL string) (*IDLFile, error) {
+	return parseIDL(rawIDL, ".", make(map[string]Schema), make(map[string]bool))
+}
+
+func parseIDLFile(file string, registry map[string]Schema, imported map[string]bool) (*IDLFile, error) {
+	contents, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	imported[filepath.Clean(file)] = true
+	return parseIDL(string(contents), filepath.Dir(file), registry, imported)
+}
+
+func parseIDL(rawIDL string, dir string, registry map[string]Schema, imported map[string]bool) (*IDLFile, error) {
+	tokens, err := lexIDL(rawIDL)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &idlParser{tokens: tokens, dir: dir, registry: registry, imported: imported, file: &IDLFile{}}
+	if err := p.parse(); err != nil {
+		return nil, err
+	}
+	if p.file.Protocol != nil {
+		p.file.Protocol.Types = p.file.Types
+	}
+	return p.file, nil
+}
+
+const (
+	idlIdentifier = iota
+	idlString
+	idlNumber
+	idlSymbol
+	idlEOF
+)
+
+type idlToken struct {
+	kind int
+	text string
+
+	// value is the string or float64 value of literals
+	value interface{}
+
+	// quoted tells whether an identifier is quoted by backticks, so it is no keyword
+	quoted bool
+
+	// doc is the doc comment immediately preceding the token
+	doc  string
+	line int
+}
+
+func (t idlToken) String() string {
+	if t.kind == idlEOF {
+		return "end of file"
+	}
+	return strconv.Quote(t.text)
+}
+
+func lexIDL(input string) ([]idlToken, error) {
+	tokens := make([]idlToken, 0)
+	doc, line := "", 1
+	for i := 0; i < len(input); {
+		c := input[i]
+		switch {
+		case c == '\n':
+			line++
+			i++
+		case c == ' ' || c == '\t' || c == '\r':
+			i++
+		case strings.HasPrefix(input[i:], "//"):
+			end := strings.IndexByte(input[i:], '\n')
+			if end < 0 {
+				end = len(input) - i
+			}
+			i += end
+		case strings.HasPrefix(input[i:], "/*"):
+			end := strings.Index(input[i+2:], "*/")
+			if end < 0 {
+				return nil, fmt.Errorf("Unterminated comment at line %d", line)
+			}
+			comment := input[i : i+end+4]
+			if strings.HasPrefix(comment, "/**") && comment != "/**/" {
+				doc = idlDoc(comment)
+			}
+			line += strings.Count(comment, "\n")
+			i += len(comment)
+		default:
+			token := idlToken{doc: doc, line: line}
+			n, err := lexIDLToken(input[i:], &token)
+			if err != nil {
+				return nil, fmt.Errorf("%v at line %d", err, line)
+			}
+			tokens = append(tokens, token)
+			doc = ""
+			i += n
+		}
+	}
+
+	return append(tokens, idlToken{kind: idlEOF, line: line}), nil
+}
+
+// lexIDLToken reads the token at the start of the given input and returns its length.
+func lexIDLToken(input string, token *idlToken) (int, error) {
+	c := input[0]
+	n := 1
+	switch {
+	case isIDLLetter(c):
+		for n < len(input) && (isIDLLetter(input[n]) || isIDLDigit(input[n]) || input[n] == '.' || input[n] == '-') {
+			n++
+		}
+		token.kind, token.text = idlIdentifier, input[:n]
+	case c == '`':
+		end := strings.IndexByte(input[1:], '`')
+		if end < 0 {
+			return 0, fmt.Errorf("Unterminated quoted identifier")
+		}
+		n = end + 2
+		token.kind, token.text, token.quoted = idlIdentifier, input[1:n-1], true
+	case c == '"':
+		for n < len(input) && input[n] != '"' {
+			if input[n] == '\\' {
+				n++
+			}
+			n++
+		}
+		if n >= len(input) {
+			return 0, fmt.Errorf("Unterminated string")
+		}
+		n++
+		var value string
+		if err := json.Unmarshal([]byte(input[:n]), &value); err != nil {
+			return 0, fmt.Errorf("Invalid string %s", input[:n])
+		}
+		token.kind, token.text, token.value = idlString, input[:n], value
+	case c == '-' || isIDLDigit(c):
+		for n < len(input) && (isIDLDigit(input[n]) || strings.IndexByte(".eE+-", input[n]) >= 0) {
+			n++
+		}
+		value, err := strconv.ParseFloat(input[:n], 64)
+		if err != nil {
+			return 0, fmt.Errorf("Invalid number %s", input[:n])
+		}
+		token.kind, token.text, token.value = idlNumber, input[:n], value
+	case strings.IndexByte("{}()[]<>,;:=@?", c) >= 0:
+		token.kind, token.text = idlSymbol, input[:1]
+	default:
+		return 0, fmt.Errorf("Unexpected character %q", c)
+	}
+
+	return n, nil
+}
+
+func isIDLLetter(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '_'
+}
+
+func isIDLDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// idlDoc returns the text of a doc comment without the leading asterisks of its lines.
+func idlDoc(comment string) string {
+	lines := strings.Split(comment[3:len(comment)-2], "\n")
+	for i, line := range lines {
+		line = strings.TrimSpace(line)
+		if i > 0 {
+			line = strings.TrimSpace(strings.TrimPrefix(line, "*"))
+		}
+		lines[i] = line
+	}
+
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+type idlParser struct {
+	tokens []idlToken
+	pos    int
+
+	// dir is the directory imports are relative to
+	dir      string
+	registry map[string]Schema
+	imported map[string]bool
+	file     *IDLFile
+
+	// namespace is the one of the protocol or namespace declaration, typeNamespace the one of the declaration being
+	// parsed and declaring the full name of the record being parsed, which its fields may refer to
+	namespace     string
+	typeNamespace string
+	declaring     string
+}
+
+func (p *idlParser) parse() error {
+	start := p.pos
+	if _, err := p.annotations(); err != nil {
+		return err
+	}
+	isProtocol := p.peek().kind == idlIdentifier && p.peek().text == protocolNameField
+	p.pos = start
+
+	if isProtocol {
+		return p.protocol()
+	}
+	return p.schemas()
+}
+
+func (p *idlParser) protocol() error {
+	doc := p.peek().doc
+	annotations, err := p.annotations()
+	if err != nil {
+		return err
+	}
+	p.keyword(protocolNameField)
+	name, err := p.identifier()
+	if err != nil {
+		return err
+	}
+	if p.namespace, err = namespaceAnnotation(annotations); err != nil {
+		return err
+	}
+	delete(annotations, schemaNamespaceField)
+
+	p.file.Protocol = &Protocol{Name: name, Namespace: p.namespace, Doc: doc, Properties: annotations,
+		Messages: make(map[string]*Message)}
+	if err := p.expect("{"); err != nil {
+		return err
+	}
+	for !p.symbol("}") {
+		if err := p.declaration(true); err != nil {
+			return err
+		}
+	}
+
+	if t := p.next(); t.kind != idlEOF {
+		return p.unexpected(t, "end of file")
+	}
+	return nil
+}
+
+// schemas parses an IDL file without a protocol, which may declare a namespace for its schemas and a main schema.
+func (p *idlParser) schemas() error {
+	mainSchema, mainNamespace := -1, ""
+	for p.peek().kind != idlEOF {
+		switch {
+		case p.keyword("namespace"):
+			namespace, err := p.identifier()
+			if err != nil {
+				return err
+			}
+			p.namespace = namespace
+		case p.keyword("schema"):
+			// the main schema may refer to schemas declared later on
+			mainSchema, mainNamespace = p.pos, p.namespace
+			for t := p.next(); !t.is(";"); t = p.next() {
+				if t.kind == idlEOF {
+					return p.unexpected(t, ";")
+				}
+			}
+			continue
+		default:
+			if err := p.declaration(false); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := p.expect(";"); err != nil {
+			return err
+		}
+	}
+
+	if mainSchema >= 0 {
+		p.pos, p.namespace, p.typeNamespace = mainSchema, mainNamespace, mainNamespace
+		v, err := p.schemaType()
+		if err != nil {
+			return err
+		}
+		if p.file.Schema, err = schemaByType(v, p.registry, mainNamespace); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// declaration parses an import, a named schema or a message of a protocol.
+func (p *idlParser) declaration(inProtocol bool) error {
+	if p.keyword("import") {
+		return p.importFile()
+	}
+
+	doc := p.peek().doc
+	annotations, err := p.annotations()
+	if err != nil {
+		return err
+	}
+	for _, typeName := range []string{typeRecord, typeError, typeEnum, typeFixed} {
+		if p.keyword(typeName) {
+			return p.namedSchema(typeName, doc, annotations)
+		}
+	}
+	if inProtocol {
+		return p.message(doc, annotations)
+	}
+
+	return p.unexpected(p.next(), "a declaration")
+}
+
+func (p *idlParser) importFile() error {
+	kind, err := p.identifier()
+	if err != nil {
+		return err
+	}
+	t := p.next()
+	if t.kind != idlString {
+		return p.unexpected(t, "a file name")
+	}
+	if err := p.expect(";"); err != nil {
+		return err
+	}
+
+	file := t.value.(string)
+	if !filepath.IsAbs(file) {
+		file = filepath.Join(p.dir, file)
+	}
+	if p.imported[file] {
+		return nil
+	}
+	p.imported[file] = true
+
+	var types []Schema
+	var messages map[string]*Message
+	var namespace string
+	switch kind {
+	case "idl":
+		var idl *IDLFile
+		if idl, err = parseIDLFile(file, p.registry, p.imported); err == nil {
+			types = idl.Types
+			if idl.Protocol != nil {
+				messages, namespace = idl.Protocol.Messages, idl.Protocol.Namespace
+			}
+		}
+	case protocolNameField:
+		var contents []byte
+		var protocol *Protocol
+		if contents, err = ioutil.ReadFile(file); err == nil {
+			if protocol, err = ParseProtocolWithRegistry(string(contents), p.registry); err == nil {
+				types, messages, namespace = protocol.Types, protocol.Messages, protocol.Namespace
+			}
+		}
+	case "schema":
+		var contents []byte
+		var schema Schema
+		if contents, err = ioutil.ReadFile(file); err == nil {
+			if schema, err = ParseSchemaWithRegistry(string(contents), p.registry); err == nil {
+				types = []Schema{schema}
+			}
+		}
+	default:
+		return fmt.Errorf("Unknown import kind %s at line %d", kind, t.line)
+	}
+	if err != nil {
+		return fmt.Errorf("Cannot import %s: %v", file, err)
+	}
+
+	p.file.Types = append(p.file.Types, types...)
+	if p.file.Protocol != nil {
+		for name, message := range messages {
+			if namespace != p.namespace {
+				message = requalifyMessage(message, namespace, p.namespace)
+			}
+			p.file.Protocol.Messages[name] = message
+		}
+	}
+	return nil
+}
+
+// requalifyMessage returns a copy of an imported message with its type references, which are relative to the
+// namespace of the protocol declaring it, made relative to the namespace of the importing protocol. The message is
+// left as it is, as the protocol it was imported from may still use it.
+func requalifyMessage(message *Message, from string, to string) *Message {
+	copied := *message
+	copied.Request = make([]*SchemaField, len(message.Request))
+	for i, field := range message.Request {
+		copiedField := *field
+		copiedField.Type = requalifyReferences(field.Type, from, to)
+		copied.Request[i] = &copiedField
+	}
+	copied.Response = requalifyReferences(message.Response, from, to)
+	copied.Errors = nil
+	for _, schema := range message.Errors {
+		copied.Errors = append(copied.Errors, requalifyReferences(schema, from, to))
+	}
+	return &copied
+}
+
+// requalifyReferences returns a copy of the given schema with requalified references, but not in the named schemas it
+// declares, which resolve references in their own namespace.
+func requalifyReferences(schema Schema, from string, to string) Schema {
+	switch s := schema.(type) {
+	case *AliasSchema:
+		copied := *s
+		copied.AliasType = referenceName(getFullName(s.AliasType, from), to)
+		return &copied
+	case *ArraySchema:
+		return &ArraySchema{Items: requalifyReferences(s.Items, from, to), Properties: s.Properties}
+	case *MapSchema:
+		return &MapSchema{Values: requalifyReferences(s.Values, from, to), Properties: s.Properties}
+	case *UnionSchema:
+		types := make([]Schema, len(s.Types))
+		for i, t := range s.Types {
+			types[i] = requalifyReferences(t, from, to)
+		}
+		return &UnionSchema{Types: types}
+	}
+	return schema
+}
+
+func (p *idlParser) namedSchema(typeName string, doc string, annotations map[string]interface{}) error {
+	name, err := p.identifier()
+	if err != nil {
+		return err
+	}
+	namespace, err := namespaceAnnotation(annotations)
+	if err != nil {
+		return err
+	}
+	if _, exists := annotations[schemaNamespaceField]; !exists {
+		namespace = p.namespace
+	}
+
+	v := map[string]interface{}{schemaTypeField: typeName, schemaNameField: name}
+	for key, value := range annotations {
+		v[key] = value
+	}
+	// named schemas know their namespace even when it is the one of the protocol, like when declared in JSON alone
+	if namespace != "" && !strings.ContainsRune(name, '.') {
+		v[schemaNamespaceField] = namespace
+	}
+	if doc != "" {
+		v[schemaDocField] = doc
+	}
+
+	var fullName string
+	fullName, p.typeNamespace = canonicalName(name, namespace, "")
+	if _, exists := p.registry[fullName]; exists {
+		return fmt.Errorf("Duplicate definition of %s", fullName)
+	}
+	switch typeName {
+	case typeEnum:
+		if v[schemaSymbolsField], err = p.symbols(); err != nil {
+			return err
+		}
+		if p.symbol("=") {
+			if v[schemaDefaultField], err = p.identifier(); err != nil {
+				return err
+			}
+		}
+		p.symbol(";")
+	case typeFixed:
+		if err := p.expect("("); err != nil {
+			return err
+		}
+		t := p.next()
+		if t.kind != idlNumber {
+			return p.unexpected(t, "the size of fixed "+name)
+		}
+		v[schemaSizeField] = t.value
+		if err := p.expect(")"); err != nil {
+			return err
+		}
+		if err := p.expect(";"); err != nil {
+			return err
+		}
+	default:
+		p.declaring = fullName
+		fields, err := p.fields()
+		p.declaring = ""
+		if err != nil {
+			return err
+		}
+		v[schemaFieldsField] = fields
+	}
+
+	schema, err := schemaByType(v, p.registry, namespace)
+	if err != nil {
+		return err
+	}
+	p.file.Types = append(p.file.Types, schema)
+	return nil
+}
+
+func (p *idlParser) symbols() ([]interface{}, error) {
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+
+	symbols := make([]interface{}, 0)
+	for !p.symbol("}") {
+		if len(symbols) > 0 {
+			if err := p.expect(","); err != nil {
+				return nil, err
+			}
+		}
+		symbol, err := p.identifier()
+		if err != nil {
+			return nil, err
+		}
+		symbols = append(symbols, symbol)
+	}
+	return symbols, nil
+}
+
+func (p *idlParser) fields() ([]interface{}, error) {
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+
+	fields := make([]interface{}, 0)
+	for !p.symbol("}") {
+		doc := p.peek().doc
+		fieldType, optional, err := p.fieldType()
+		if err != nil {
+			return nil, err
+		}
+		for {
+			field, err := p.variable(doc, fieldType, optional)
+			if err != nil {
+				return nil, err
+			}
+			fields = append(fields, field)
+			if p.symbol(";") {
+				break
+			}
+			if err := p.expect(","); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return fields, nil
+}
+
+// fieldType parses the type of fields and parameters, which is optional if followed by a question mark.
+func (p *idlParser) fieldType() (interface{}, bool, error) {
+	v, err := p.schemaType()
+	if err != nil {
+		return nil, false, err
+	}
+
+	return v, p.symbol("?"), nil
+}
+
+// variable parses the name and default value of a field or parameter with the given type.
+func (p *idlParser) variable(doc string, fieldType interface{}, optional bool) (map[string]interface{}, error) {
+	if t := p.peek(); t.doc != "" {
+		doc = t.doc
+	}
+	annotations, err := p.annotations()
+	if err != nil {
+		return nil, err
+	}
+	name, err := p.identifier()
+	if err != nil {
+		return nil, err
+	}
+
+	field := map[string]interface{}{schemaNameField: name}
+	for key, value := range annotations {
+		field[key] = value
+	}
+	if doc != "" {
+		field[schemaDocField] = doc
+	}
+	if p.symbol("=") {
+		if field[schemaDefaultField], err = p.jsonValue(); err != nil {
+			return nil, err
+		}
+	}
+
+	field[schemaTypeField] = fieldType
+	if optional {
+		// the default value belongs to the first branch of a union
+		if def, exists := field[schemaDefaultField]; exists && def != nil {
+			field[schemaTypeField] = []interface{}{fieldType, typeNull}
+		} else {
+			field[schemaTypeField] = []interface{}{typeNull, fieldType}
+		}
+	}
+	return field, nil
+}
+
+// schemaType parses a type with its annotations into its JSON representation.
+func (p *idlParser) schemaType() (interface{}, error) {
+	annotations, err := p.annotations()
+	if err != nil {
+		return nil, err
+	}
+	t := p.next()
+	if t.kind != idlIdentifier {
+		return nil, p.unexpected(t, "a type")
+	}
+	if t.quoted {
+		return p.resolve(t.text), nil
+	}
+
+	var v map[string]interface{}
+	switch t.text {
+	case typeArray, typeMap:
+		if err := p.expect("<"); err != nil {
+			return nil, err
+		}
+		elem, err := p.schemaType()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(">"); err != nil {
+			return nil, err
+		}
+		v = map[string]interface{}{schemaTypeField: t.text, schemaItemsField: elem}
+		if t.text == typeMap {
+			v = map[string]interface{}{schemaTypeField: t.text, schemaValuesField: elem}
+		}
+	case typeUnion:
+		if err := p.expect("{"); err != nil {
+			return nil, err
+		}
+		types := make([]interface{}, 0)
+		for !p.symbol("}") {
+			if len(types) > 0 {
+				if err := p.expect(","); err != nil {
+					return nil, err
+				}
+			}
+			branch, err := p.schemaType()
+			if err != nil {
+				return nil, err
+			}
+			types = append(types, branch)
+		}
+		return types, nil
+	case typeNull, typeBoolean, typeInt, typeLong, typeFloat, typeDouble, typeBytes, typeString:
+		v = map[string]interface{}{schemaTypeField: t.text}
+	case "date":
+		v = map[string]interface{}{schemaTypeField: typeInt, schemaLogicalTypeField: DateLogicalType}
+	case "time_ms":
+		v = map[string]interface{}{schemaTypeField: typeInt, schemaLogicalTypeField: TimeMillisLogicalType}
+	case "timestamp_ms":
+		v = map[string]interface{}{schemaTypeField: typeLong, schemaLogicalTypeField: TimestampMillisLogicalType}
+	case "local_timestamp_ms":
+		v = map[string]interface{}{schemaTypeField: typeLong, schemaLogicalTypeField: LocalTimestampMillisLogicalType}
+	case "uuid":
+		v = map[string]interface{}{schemaTypeField: typeString, schemaLogicalTypeField: UUIDLogicalType}
+	case DecimalLogicalType:
+		v = map[string]interface{}{schemaTypeField: typeBytes, schemaLogicalTypeField: DecimalLogicalType}
+		if err := p.expect("("); err != nil {
+			return nil, err
+		}
+		for _, attribute := range []string{schemaPrecisionField, schemaScaleField} {
+			if attribute == schemaScaleField {
+				if err := p.expect(","); err != nil {
+					return nil, err
+				}
+			}
+			n := p.next()
+			if n.kind != idlNumber {
+				return nil, p.unexpected(n, "the "+attribute+" of a decimal")
+			}
+			v[attribute] = n.value
+		}
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+	default:
+		return p.resolve(t.text), nil
+	}
+
+	for key, value := range annotations {
+		v[key] = value
+	}
+	return v, nil
+}
+
+func (p *idlParser) message(doc string, annotations map[string]interface{}) error {
+	p.typeNamespace = p.namespace
+	var response interface{} = typeNull
+	if !p.keyword("void") {
+		var err error
+		if response, err = p.schemaType(); err != nil {
+			return err
+		}
+	}
+	name, err := p.identifier()
+	if err != nil {
+		return err
+	}
+
+	if err := p.expect("("); err != nil {
+		return err
+	}
+	request := make([]interface{}, 0)
+	for !p.symbol(")") {
+		if len(request) > 0 {
+			if err := p.expect(","); err != nil {
+				return err
+			}
+		}
+		doc := p.peek().doc
+		paramType, optional, err := p.fieldType()
+		if err != nil {
+			return err
+		}
+		param, err := p.variable(doc, paramType, optional)
+		if err != nil {
+			return err
+		}
+		request = append(request, param)
+	}
+
+	v := map[string]interface{}{messageRequestField: request, messageResponseField: response}
+	for key, value := range annotations {
+		v[key] = value
+	}
+	if doc != "" {
+		v[schemaDocField] = doc
+	}
+	if p.keyword("oneway") {
+		v[messageOneWayField] = true
+	} else if p.keyword("throws") {
+		errors := make([]interface{}, 0)
+		for len(errors) == 0 || p.symbol(",") {
+			errorName, err := p.identifier()
+			if err != nil {
+				return err
+			}
+			errors = append(errors, p.resolve(errorName))
+		}
+		v[messageErrorsField] = errors
+	}
+	if err := p.expect(";"); err != nil {
+		return err
+	}
+
+	message, err := parseMessage(name, v, p.registry, p.namespace)
+	if err != nil {
+		return err
+	}
+	p.file.Protocol.Messages[name] = message
+	return nil
+}
+
+// annotations parses annotations like @namespace("example") into their names and JSON values.
+func (p *idlParser) annotations() (map[string]interface{}, error) {
+	annotations := make(map[string]interface{})
+	for p.symbol("@") {
+		name, err := p.identifier()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect("("); err != nil {
+			return nil, err
+		}
+		if annotations[name], err = p.jsonValue(); err != nil {
+			return nil, err
+		}
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+	}
+
+	return annotations, nil
+}
+
+// jsonValue parses a JSON value, like the ones of default values and annotations.
+func (p *idlParser) jsonValue() (interface{}, error) {
+	t := p.next()
+	switch {
+	case t.kind == idlString || t.kind == idlNumber:
+		return t.value, nil
+	case t.kind == idlIdentifier && !t.quoted && t.text == "true":
+		return true, nil
+	case t.kind == idlIdentifier && !t.quoted && t.text == "false":
+		return false, nil
+	case t.kind == idlIdentifier && !t.quoted && t.text == typeNull:
+		return nil, nil
+	case t.is("["):
+		values := make([]interface{}, 0)
+		for !p.symbol("]") {
+			if len(values) > 0 {
+				if err := p.expect(","); err != nil {
+					return nil, err
+				}
+			}
+			value, err := p.jsonValue()
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, value)
+		}
+		return values, nil
+	case t.is("{"):
+		values := make(map[string]interface{})
+		for !p.symbol("}") {
+			if len(values) > 0 {
+				if err := p.expect(","); err != nil {
+					return nil, err
+				}
+			}
+			key := p.next()
+			if key.kind != idlString {
+				return nil, p.unexpected(key, "a JSON string")
+			}
+			if err := p.expect(":"); err != nil {
+				return nil, err
+			}
+			value, err := p.jsonValue()
+			if err != nil {
+				return nil, err
+			}
+			values[key.value.(string)] = value
+		}
+		return values, nil
+	}
+
+	return nil, p.unexpected(t, "a JSON value")
+}
+
+// resolve returns the full name of a named schema referred to by the given name, which is looked up in the namespace
+// of the declaration and the one of the file. Unknown names are returned as they are.
+func (p *idlParser) resolve(name string) string {
+	if strings.ContainsRune(name, '.') {
+		return name
+	}
+
+	for _, namespace := range []string{p.typeNamespace, p.namespace} {
+		fullName := getFullName(name, namespace)
+		if _, exists := p.registry[fullName]; exists || fullName == p.declaring {
+			return fullName
+		}
+	}
+	return name
+}
+
+func (p *idlParser) peek() idlToken {
+	return p.tokens[p.pos]
+}
+
+func (p *idlParser) next() idlToken {
+	t := p.tokens[p.pos]
+	if t.kind != idlEOF {
+		p.pos++
+	}
+	return t
+}
+
+// keyword skips the next token if it is the given keyword.
+func (p *idlParser) keyword(word string) bool {
+	if t := p.peek(); t.kind == idlIdentifier && !t.quoted && t.text == word {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+// symbol skips the next token if it is the given symbol.
+func (p *idlParser) symbol(symbol string) bool {
+	if p.peek().is(symbol) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *idlParser) expect(symbol string) error {
+	if t := p.next(); !t.is(symbol) {
+		return p.unexpected(t, symbol)
+	}
+	return nil
+}
+
+func (p *idlParser) identifier() (string, error) {
+	t := p.next()
+	if t.kind != idlIdentifier {
+		return "", p.unexpected(t, "a name")
+	}
+	return t.text, nil
+}
+
+func (p *idlParser) unexpected(t idlToken, expected string) error {
+	return fmt.Errorf("Expected %s at line %d, found %s", expected, t.line, t)
+}
+
+func (t idlToken) is(symbol string) bool {
+	return t.kind == idlSymbol && t.text == symbol
+}
+
+func namespaceAnnotation(annotations map[string]interface{}) (string, error) {
+	namespace, exists := annotations[schemaNamespaceField]
+	if !exists {
+		return "", nil
+	}
+	if s, ok := namespace.(string); ok {
+		return s, nil
+	}
+	return "", fmt.Errorf("Invalid namespace: %v", namespace)
+}
@@ -0,0 +1,180 @@
+package avro
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseIDLProtocol(t *testing.T) {
+	idl, err := ParseIDLFile("test/idl/simple.avdl")
+	assert(t, err, nil)
+	assert(t, idl.Schema, nil)
+
+	protocol := idl.Protocol
+	assert(t, protocol.Name, "Simple")
+	assert(t, protocol.Namespace, "example.simple")
+	assert(t, protocol.Doc, "A simple protocol.")
+	version, _ := protocol.Prop("version")
+	assert(t, version, "1.0")
+
+	var names []string
+	for _, schema := range protocol.Types {
+		names = append(names, GetFullName(schema))
+	}
+	assert(t, names, []string{"example.common.Kind", "example.common.MD5", "example.status.Unavailable", "example.avro.Complex",
+		"example.simple.TestRecord", "example.simple.TestError"})
+
+	kind := protocol.Types[0].(*EnumSchema)
+	assert(t, kind.Doc, "The kind of a thing.")
+	assert(t, kind.Aliases, []string{"example.common.KindOf"})
+	assert(t, kind.Default, "FOO")
+
+	record := protocol.Types[4].(*RecordSchema)
+	assert(t, record.Doc, "A record to test.")
+	parsed := MustParseSchema(`{"type": "record", "name": "TestRecord", "namespace": "example.simple", "fields": [
+		{"name": "name", "type": "string", "default": "foo", "order": "ignore"},
+		{"name": "kind", "type": {"type": "enum", "name": "Kind", "namespace": "example.common", "symbols": ["FOO", "BAR", "BAZ"]}},
+		{"name": "hash", "type": {"type": "fixed", "name": "MD5", "namespace": "example.common", "size": 16}},
+		{"name": "nullableHash", "type": ["example.common.MD5", "null"], "aliases": ["hash"], "default": null},
+		{"name": "arrayOfLongs", "type": {"type": "array", "items": "long"}},
+		{"name": "properties", "type": {"type": "map", "values": "string"}},
+		{"name": "amount", "type": {"type": "bytes", "logicalType": "decimal", "precision": 9, "scale": 2}},
+		{"name": "birthday", "type": {"type": "int", "logicalType": "date"}},
+		{"name": "created", "type": {"type": "long", "logicalType": "timestamp-millis"}},
+		{"name": "updated", "type": {"type": "long", "logicalType": "timestamp-micros"}},
+		{"name": "id", "type": {"type": "string", "logicalType": "uuid"}},
+		{"name": "nickname", "type": ["null", "string"]},
+		{"name": "a", "type": "int"},
+		{"name": "b", "type": "int", "default": 2},
+		{"name": "next", "type": ["null", "TestRecord"]},
+		{"name": "complex", "type": "int"}
+	]}`).(*RecordSchema)
+	assert(t, len(record.Fields), len(parsed.Fields))
+	// the last fields refer to records not declared in parsed
+	for i := 0; i < len(parsed.Fields)-2; i++ {
+		assert(t, CanonicalForm(record.Fields[i].Type), CanonicalForm(parsed.Fields[i].Type))
+		assert(t, record.Fields[i].Name, parsed.Fields[i].Name)
+		assert(t, record.Fields[i].Default, parsed.Fields[i].Default)
+		assert(t, record.Fields[i].Order, parsed.Fields[i].Order)
+	}
+	assert(t, record.Fields[0].Doc, "The name.")
+	prop, _ := record.Fields[0].Prop("foo")
+	assert(t, prop, "bar")
+	assert(t, record.Fields[3].Aliases, []string{"hash"})
+	assert(t, record.Fields[13].Doc, "Documented b.")
+	assert(t, record.Fields[14].Type.(*UnionSchema).Types[1].(*AliasSchema).RefSchema, &RecursiveSchema{Actual: record})
+	assert(t, record.Fields[15].Type.(*AliasSchema).RefSchema, &RecursiveSchema{Actual: protocol.Types[3].(*RecordSchema)})
+	assert(t, protocol.Types[5].(*RecordSchema).IsError, true)
+
+	assert(t, len(protocol.Messages), 6)
+	assert(t, protocol.Messages["hello"].Response, Schema(new(StringSchema)))
+	assert(t, protocol.Messages["add"].Request[1].Default, int32(0))
+	assert(t, protocol.Messages["error"].Errors[0].(*AliasSchema).AliasType, "TestError")
+	assert(t, protocol.Messages["ping"].OneWay, true)
+	deprecated, _ := protocol.Messages["ping"].Prop("deprecated")
+	assert(t, deprecated, true)
+	assert(t, protocol.Messages["status"].Errors[0].(*AliasSchema).AliasType, "example.status.Unavailable")
+	reparsed, err := ParseProtocol(protocol.String())
+	assert(t, err, nil)
+	assert(t, reparsed.String(), protocol.String())
+
+	idl, err = ParseIDL(`@namespace("example") @version("1.0") protocol P { error E { string message; } record R { E e; }
+		@deprecated(true) R get(int id) throws E; }`)
+	assert(t, err, nil)
+	reparsed, err = ParseProtocol(idl.Protocol.String())
+	assert(t, err, nil)
+	assert(t, reparsed.String(), idl.Protocol.String())
+	version, _ = reparsed.Prop("version")
+	assert(t, version, "1.0")
+	deprecated, _ = reparsed.Messages["get"].Prop("deprecated")
+	assert(t, deprecated, true)
+
+	// references to types in other namespaces are full names
+	idl, err = ParseIDL(`@namespace("x") protocol P { @namespace("a.b") record R {} record S { a.b.R r; } a.b.R get(S s); }`)
+	assert(t, err, nil)
+	s := idl.Protocol.Types[1].(*RecordSchema)
+	assert(t, s.Fields[0].Type.(*AliasSchema).AliasType, "a.b.R")
+	assert(t, idl.Protocol.Messages["get"].Response.(*AliasSchema).AliasType, "a.b.R")
+	assert(t, idl.Protocol.Messages["get"].Request[0].Type.(*AliasSchema).AliasType, "S")
+	reparsed, err = ParseProtocol(idl.Protocol.String())
+	assert(t, err, nil)
+	assert(t, reparsed.String(), idl.Protocol.String())
+	assert(t, reparsed.Types[1].(*RecordSchema).Fields[0].Type.(*AliasSchema).RefSchema,
+		&RecursiveSchema{Actual: reparsed.Types[0].(*RecordSchema)})
+	assert(t, GetFullName(reparsed.Types[0]), "a.b.R")
+}
+
+func TestParseIDLSchemas(t *testing.T) {
+	idl, err := ParseIDLFile("test/idl/schemas.avdl")
+	assert(t, err, nil)
+	assert(t, idl.Protocol, (*Protocol)(nil))
+	assert(t, len(idl.Types), 2)
+	assert(t, CanonicalForm(idl.Schema), `{"type":"array","items":{"name":"example.schemas.Person","type":"record","fields":[`+
+		`{"name":"name","type":"string"},{"name":"address","type":["null",{"name":"example.places.Address","type":"record","fields":[`+
+		`{"name":"street","type":"string"}]}]},{"name":"partner","type":["null","example.schemas.Person"]}]}}`)
+	assert(t, idl.Types[0].(*RecordSchema).Doc, "Where a person lives.")
+}
+
+func TestParseIDLErrors(t *testing.T) {
+	_, err := ParseIDL(`protocol P { record R { Unknown field; } }`)
+	assert(t, err.Error(), "Unknown type name: Unknown")
+
+	_, err = ParseIDL(`protocol P { record R { string name } }`)
+	assert(t, err.Error(), `Expected , at line 1, found "}"`)
+
+	_, err = ParseIDL(`protocol P { enum E { A } enum E { B } }`)
+	assert(t, err.Error(), "Duplicate definition of E")
+
+	_, err = ParseIDL(`protocol P { string ping() oneway; }`)
+	assert(t, err.Error(), "One-way message ping must have a null response and no errors")
+
+	_, err = ParseIDL("protocol P {\n /* unterminated")
+	assert(t, err.Error(), "Unterminated comment at line 2")
+
+	_, err = ParseIDL(`protocol P { import thing "simple.avdl"; }`)
+	assert(t, err.Error(), "Unknown import kind thing at line 1")
+
+	if _, err = ParseIDL(`protocol P { import idl "missing.avdl"; }`); err == nil {
+		t.Fatal("Expected an error importing a missing file")
+	}
+
+	// a bad file is detected when parsed or loaded
+	dir, err := ioutil.TempDir("", "idl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	broken := filepath.Join(dir, "broken.avdl")
+	if err = ioutil.WriteFile(broken, []byte(`protocol P { record R { Unknown field; } }`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	_, err = ParseIDLFile(broken)
+	assert(t, err.Error(), "Unknown type name: Unknown")
+	assert(t, LoadIDLSchemas(broken), map[string]Schema{})
+	assert(t, LoadIDLSchemas(dir+"/"), map[string]Schema{})
+}
+
+func TestRequalifyMessage(t *testing.T) {
+	protocol, err := ParseProtocolFile("test/idl/status.avpr")
+	assert(t, err, nil)
+	message := protocol.Messages["status"]
+	requalified := requalifyMessage(message, protocol.Namespace, "example.other")
+	assert(t, requalified.Errors[0].(*AliasSchema).AliasType, "example.status.Unavailable")
+	// the protocol the message is imported from is left as it is
+	assert(t, message.Errors[0].(*AliasSchema).AliasType, "Unavailable")
+	assert(t, requalified.Errors[0].(*AliasSchema).RefSchema, message.Errors[0].(*AliasSchema).RefSchema)
+}
+
+func TestLoadIDLSchemas(t *testing.T) {
+	schemas := LoadIDLSchemas("test/idl/")
+	assert(t, len(schemas), 11)
+
+	_, exists := schemas["example.simple.TestRecord"]
+	assert(t, exists, true)
+	_, exists = schemas["example.avro.foo"]
+	assert(t, exists, true)
+	_, exists = schemas["example.places.Address"]
+	assert(t, exists, true)
+}
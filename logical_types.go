@@ -0,0 +1,544 @@
+package avro
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// Support for logical types, which annotate primitive and fixed schemas with the meaning of their values.
+// Spec: https://avro.apache.org/docs/current/spec.html#Logical+Types
+
+const (
+	schemaLogicalTypeField = "logicalType"
+	schemaPrecisionField   = "precision"
+	schemaScaleField       = "scale"
+
+	// maxDecimalDigits caps the precision of decimals, which a bytes schema does not bound otherwise, so that no schema
+	// makes checking the precision of values arbitrarily expensive.
+	maxDecimalDigits = 1000
+)
+
+// Names of the logical types known to this package.
+const (
+	// DecimalLogicalType represents arbitrary-precision decimal numbers stored in bytes or fixed schemas.
+	// Values are mapped to *big.Rat.
+	DecimalLogicalType = "decimal"
+
+	// DateLogicalType represents days since the Unix epoch stored in int schemas. Values are mapped to time.Time at
+	// midnight UTC, written values are taken at their date in their own location.
+	DateLogicalType = "date"
+
+	// TimeMillisLogicalType represents milliseconds after midnight stored in int schemas, with no reference to a
+	// particular date or time zone. Values are mapped to time.Duration.
+	TimeMillisLogicalType = "time-millis"
+
+	// TimeMicrosLogicalType represents microseconds after midnight stored in long schemas, with no reference to a
+	// particular date or time zone. Values are mapped to time.Duration.
+	TimeMicrosLogicalType = "time-micros"
+
+	// TimestampMillisLogicalType represents an instant as milliseconds since the Unix epoch stored in long schemas.
+	// Values are mapped to time.Time in UTC.
+	TimestampMillisLogicalType = "timestamp-millis"
+
+	// TimestampMicrosLogicalType represents an instant as microseconds since the Unix epoch stored in long schemas.
+	// Values are mapped to time.Time in UTC.
+	TimestampMicrosLogicalType = "timestamp-micros"
+
+	// LocalTimestampMillisLogicalType represents a wall clock reading in milliseconds stored in long schemas, as if the
+	// clock was in UTC, regardless of the time zone considered local. Values are mapped to time.Time with the same wall
+	// clock reading in time.Local, written values are taken at their wall clock reading in their own location.
+	LocalTimestampMillisLogicalType = "local-timestamp-millis"
+
+	// LocalTimestampMicrosLogicalType is the same as LocalTimestampMillisLogicalType with microsecond precision.
+	LocalTimestampMicrosLogicalType = "local-timestamp-micros"
+
+	// UUIDLogicalType represents universally unique identifiers stored in string schemas. Written strings must be
+	// valid UUIDs, fields of specific structs may be of type UUID.
+	UUIDLogicalType = "uuid"
+
+	// DurationLogicalType represents an amount of time stored in fixed schemas of size 12. Values are mapped to
+	// Duration.
+	DurationLogicalType = "duration"
+)
+
+// LogicalType is the logical type of a schema, as given by its logicalType attribute.
+// Logical types that are unknown to this package or invalid for their schema are ignored when parsing schemas, as
+// required by the spec, so values are read and written as the underlying type.
+type LogicalType interface {
+	// Name returns the value of the logicalType attribute, e.g. DecimalLogicalType.
+	Name() string
+
+	// properties returns the attributes of the logical type to add to the JSON representation of its schema.
+	properties() map[string]interface{}
+}
+
+// DecimalType is the decimal logical type. The unscaled value of a decimal is stored as a two's-complement big-endian
+// integer, the decimal being unscaled * 10^-Scale.
+type DecimalType struct {
+	// Precision is the maximum number of digits of the unscaled value.
+	Precision int
+
+	// Scale is the number of digits to the right of the decimal point.
+	Scale int
+}
+
+// Name returns DecimalLogicalType.
+func (*DecimalType) Name() string {
+	return DecimalLogicalType
+}
+
+func (t *DecimalType) properties() map[string]interface{} {
+	return map[string]interface{}{
+		schemaLogicalTypeField: DecimalLogicalType,
+		schemaPrecisionField:   t.Precision,
+		schemaScaleField:       t.Scale,
+	}
+}
+
+// TemporalType is one of the date and time logical types, e.g. TemporalType(DateLogicalType).
+type TemporalType string
+
+// Name returns the name of this TemporalType.
+func (t TemporalType) Name() string {
+	return string(t)
+}
+
+func (t TemporalType) properties() map[string]interface{} {
+	return map[string]interface{}{schemaLogicalTypeField: string(t)}
+}
+
+// UUIDType is the uuid logical type.
+type UUIDType struct{}
+
+// Name returns UUIDLogicalType.
+func (*UUIDType) Name() string {
+	return UUIDLogicalType
+}
+
+func (*UUIDType) properties() map[string]interface{} {
+	return map[string]interface{}{schemaLogicalTypeField: UUIDLogicalType}
+}
+
+// DurationType is the duration logical type.
+type DurationType struct{}
+
+// Name returns DurationLogicalType.
+func (*DurationType) Name() string {
+	return DurationLogicalType
+}
+
+func (*DurationType) properties() map[string]interface{} {
+	return map[string]interface{}{schemaLogicalTypeField: DurationLogicalType}
+}
+
+// GetLogicalType returns the logical type of the given schema or nil if it has none.
+func GetLogicalType(schema Schema) LogicalType {
+	switch s := schema.(type) {
+	case *StringSchema:
+		return s.LogicalType
+	case *IntSchema:
+		return s.LogicalType
+	case *LongSchema:
+		return s.LogicalType
+	case *BytesSchema:
+		return s.LogicalType
+	case *FixedSchema:
+		return s.LogicalType
+	case *AliasSchema:
+		return GetLogicalType(s.RefSchema)
+	}
+
+	return nil
+}
+
+// parseLogicalType parses the logical type of the given underlying schema from its JSON attributes.
+// Returns nil if there is no logical type, it is not known or it is invalid for the schema.
+func parseLogicalType(v map[string]interface{}, schema Schema) LogicalType {
+	name, ok := v[schemaLogicalTypeField].(string)
+	if !ok {
+		return nil
+	}
+
+	switch name {
+	case DecimalLogicalType:
+		return parseDecimalType(v, schema)
+	case DateLogicalType, TimeMillisLogicalType:
+		if _, ok := schema.(*IntSchema); ok {
+			return TemporalType(name)
+		}
+	case TimeMicrosLogicalType, TimestampMillisLogicalType, TimestampMicrosLogicalType,
+		LocalTimestampMillisLogicalType, LocalTimestampMicrosLogicalType:
+		if _, ok := schema.(*LongSchema); ok {
+			return TemporalType(name)
+		}
+	case UUIDLogicalType:
+		if _, ok := schema.(*StringSchema); ok {
+			return &UUIDType{}
+		}
+	case DurationLogicalType:
+		if fixed, ok := schema.(*FixedSchema); ok && fixed.Size == durationSize {
+			return &DurationType{}
+		}
+	}
+
+	return nil
+}
+
+// parseDecimalType returns nil for a precision or scale that is missing, out of range or does not fit into the size
+// of a fixed schema.
+func parseDecimalType(v map[string]interface{}, schema Schema) LogicalType {
+	maxPrecision := maxDecimalDigits
+	switch s := schema.(type) {
+	case *BytesSchema:
+	case *FixedSchema:
+		// a byte holds more than two digits, so larger fixeds hold the most digits allowed
+		if s.Size < maxDecimalDigits/2 && maxDecimalPrecision(s.Size) < maxPrecision {
+			maxPrecision = maxDecimalPrecision(s.Size)
+		}
+	default:
+		return nil
+	}
+
+	precision, ok := jsonInt(v[schemaPrecisionField])
+	if !ok || precision < 1 || precision > maxPrecision {
+		return nil
+	}
+
+	scale := 0
+	if _, exists := v[schemaScaleField]; exists {
+		if scale, ok = jsonInt(v[schemaScaleField]); !ok || scale < 0 || scale > precision {
+			return nil
+		}
+	}
+
+	return &DecimalType{Precision: precision, Scale: scale}
+}
+
+// maxDecimalPrecision returns the number of digits that always fit into a two's-complement integer of the given size.
+func maxDecimalPrecision(size int) int {
+	if size < 1 {
+		return 0
+	}
+
+	max := new(big.Int).Lsh(big.NewInt(1), uint(8*size-1))
+	return len(max.Sub(max, big.NewInt(1)).String()) - 1
+}
+
+func jsonInt(value interface{}) (int, bool) {
+	number, ok := jsonNumber(value)
+	if !ok || number != math.Trunc(number) || number > math.MaxInt32 || number < math.MinInt32 {
+		return 0, false
+	}
+
+	return int(number), true
+}
+
+// logicalTypeJSON returns the JSON representation of a schema of the given type with a logical type.
+func logicalTypeJSON(typeName string, logicalType LogicalType) map[string]interface{} {
+	v := logicalType.properties()
+	v[schemaTypeField] = typeName
+	return v
+}
+
+var (
+	ratType      = reflect.TypeOf(big.Rat{})
+	bytesType    = reflect.TypeOf([]byte(nil))
+	timeType     = reflect.TypeOf(time.Time{})
+	durationType = reflect.TypeOf(time.Duration(0))
+	uuidType     = reflect.TypeOf(UUID{})
+)
+
+// ratValue extracts a decimal from the given value if it is a *big.Rat or big.Rat.
+func ratValue(v reflect.Value) (*big.Rat, bool) {
+	if v.Kind() == reflect.Interface {
+		v = v.Elem()
+	}
+	if !v.IsValid() {
+		return nil, false
+	}
+
+	switch value := v.Interface().(type) {
+	case *big.Rat:
+		return value, value != nil
+	case big.Rat:
+		return &value, true
+	}
+	return nil, false
+}
+
+// isDecimal checks whether the given value may be written as a decimal of the given schema.
+func isDecimal(schema Schema, v reflect.Value) bool {
+	if _, ok := GetLogicalType(schema).(*DecimalType); ok {
+		_, ok = ratValue(v)
+		return ok
+	}
+
+	return false
+}
+
+// encode returns the two's-complement big-endian form of the unscaled value of the given decimal, sign extended to
+// the given size or as short as possible if the size is zero.
+func (t *DecimalType) encode(value *big.Rat, size int) ([]byte, error) {
+	if t.Precision > maxDecimalDigits || t.Scale > maxDecimalDigits {
+		return nil, fmt.Errorf("Decimal precision %d or scale %d exceeds %d", t.Precision, t.Scale, maxDecimalDigits)
+	}
+	unscaled := new(big.Rat).Mul(value, new(big.Rat).SetInt(pow10(t.Scale)))
+	if !unscaled.IsInt() {
+		return nil, fmt.Errorf("Decimal %s does not fit into scale %d", value.RatString(), t.Scale)
+	}
+	n := unscaled.Num()
+	if new(big.Int).Abs(n).Cmp(pow10(t.Precision)) >= 0 {
+		return nil, fmt.Errorf("Decimal %s does not fit into precision %d", value.RatString(), t.Precision)
+	}
+
+	var b []byte
+	var sign byte
+	if n.Sign() >= 0 {
+		b = n.Bytes()
+		if len(b) == 0 || b[0]&0x80 != 0 {
+			b = append([]byte{0}, b...)
+		}
+	} else {
+		// the two's complement of n is the complement of -n-1
+		b = new(big.Int).Not(n).Bytes()
+		for i := range b {
+			b[i] = ^b[i]
+		}
+		if len(b) == 0 || b[0]&0x80 == 0 {
+			b = append([]byte{0xFF}, b...)
+		}
+		sign = 0xFF
+	}
+
+	if size > 0 {
+		if len(b) > size {
+			return nil, fmt.Errorf("Decimal %s does not fit into %d bytes", value.RatString(), size)
+		}
+		fixed := make([]byte, size)
+		for i := 0; i < size-len(b); i++ {
+			fixed[i] = sign
+		}
+		copy(fixed[size-len(b):], b)
+		b = fixed
+	}
+	return b, nil
+}
+
+// decode returns the decimal of the given two's-complement big-endian unscaled value.
+func (t *DecimalType) decode(b []byte) *big.Rat {
+	n := new(big.Int).SetBytes(b)
+	if len(b) > 0 && b[0]&0x80 != 0 {
+		n.Sub(n, new(big.Int).Lsh(big.NewInt(1), uint(8*len(b))))
+	}
+
+	return new(big.Rat).SetFrac(n, pow10(t.Scale))
+}
+
+var pow10Cache = make(map[int]*big.Int)
+var pow10CacheLock sync.RWMutex
+
+// pow10 returns 10^n, computed once for every n. The result must not be modified.
+func pow10(n int) *big.Int {
+	pow10CacheLock.RLock()
+	p, ok := pow10Cache[n]
+	pow10CacheLock.RUnlock()
+	if ok {
+		return p
+	}
+
+	p = new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+	pow10CacheLock.Lock()
+	pow10Cache[n] = p
+	pow10CacheLock.Unlock()
+	return p
+}
+
+// temporalValue converts the given value to the underlying value of the given schema if the schema has a TemporalType
+// and the value is a time.Time or time.Duration, whichever the type is mapped to.
+func temporalValue(schema Schema, v reflect.Value) (int64, bool) {
+	temporalType, ok := GetLogicalType(schema).(TemporalType)
+	if !ok {
+		return 0, false
+	}
+	if v.Kind() == reflect.Interface {
+		v = v.Elem()
+	}
+	v = dereference(v)
+	if !v.IsValid() || v.Type() != temporalType.goType() {
+		return 0, false
+	}
+
+	switch value := v.Interface().(type) {
+	case time.Duration:
+		if temporalType == TimeMillisLogicalType {
+			return int64(value / time.Millisecond), true
+		}
+		return int64(value / time.Microsecond), true
+	case time.Time:
+		switch temporalType {
+		case DateLogicalType:
+			year, month, day := value.Date()
+			return time.Date(year, month, day, 0, 0, 0, 0, time.UTC).Unix() / secondsPerDay, true
+		case LocalTimestampMillisLogicalType, LocalTimestampMicrosLogicalType:
+			value = wallClock(value, time.UTC)
+		}
+		if temporalType == TimestampMillisLogicalType || temporalType == LocalTimestampMillisLogicalType {
+			return value.Unix()*1000 + int64(value.Nanosecond())/int64(time.Millisecond), true
+		}
+		return value.Unix()*1000000 + int64(value.Nanosecond())/int64(time.Microsecond), true
+	}
+	return 0, false
+}
+
+const secondsPerDay = 24 * 60 * 60
+
+// goType returns the type values of this TemporalType are mapped to.
+func (t TemporalType) goType() reflect.Type {
+	if t == TimeMillisLogicalType || t == TimeMicrosLogicalType {
+		return durationType
+	}
+	return timeType
+}
+
+// decode returns the time.Time or time.Duration of the given underlying value.
+func (t TemporalType) decode(n int64) interface{} {
+	switch t {
+	case DateLogicalType:
+		return time.Unix(n*secondsPerDay, 0).UTC()
+	case TimeMillisLogicalType:
+		return time.Duration(n) * time.Millisecond
+	case TimeMicrosLogicalType:
+		return time.Duration(n) * time.Microsecond
+	}
+
+	unit := int64(1000000)
+	if t == TimestampMillisLogicalType || t == LocalTimestampMillisLogicalType {
+		unit = 1000
+	}
+	seconds, fraction := n/unit, n%unit
+	if fraction < 0 {
+		seconds, fraction = seconds-1, fraction+unit
+	}
+	value := time.Unix(seconds, fraction*(int64(time.Second)/unit)).UTC()
+	if t == LocalTimestampMillisLogicalType || t == LocalTimestampMicrosLogicalType {
+		return wallClock(value, time.Local)
+	}
+	return value
+}
+
+// wallClock returns the time with the same wall clock reading as the given time in the given location.
+func wallClock(t time.Time, loc *time.Location) time.Time {
+	year, month, day := t.Date()
+	hour, min, sec := t.Clock()
+	return time.Date(year, month, day, hour, min, sec, t.Nanosecond(), loc)
+}
+
+// UUID is a universally unique identifier, written to uuid strings in its canonical textual form, e.g.
+// "123e4567-e89b-12d3-a456-426655440000".
+type UUID [16]byte
+
+// ParseUUID parses a UUID from its canonical textual form, accepting upper and lower case hex digits.
+func ParseUUID(s string) (UUID, error) {
+	var u UUID
+	if len(s) != 36 || s[8] != '-' || s[13] != '-' || s[18] != '-' || s[23] != '-' {
+		return u, fmt.Errorf("Invalid uuid: %q", s)
+	}
+
+	digits := s[0:8] + s[9:13] + s[14:18] + s[19:23] + s[24:36]
+	if _, err := hex.Decode(u[:], []byte(digits)); err != nil {
+		return u, fmt.Errorf("Invalid uuid: %q", s)
+	}
+	return u, nil
+}
+
+// String returns the canonical textual form of this UUID.
+func (u UUID) String() string {
+	s := hex.EncodeToString(u[:])
+	return s[0:8] + "-" + s[8:12] + "-" + s[12:16] + "-" + s[16:20] + "-" + s[20:32]
+}
+
+// uuidString returns the string to write to a schema with the uuid logical type for the given value, which must be
+// either a valid UUID string or a UUID.
+func uuidString(v reflect.Value) (string, bool) {
+	if v.Kind() == reflect.Interface {
+		v = v.Elem()
+	}
+	v = dereference(v)
+	if !v.IsValid() {
+		return "", false
+	}
+
+	switch value := v.Interface().(type) {
+	case UUID:
+		return value.String(), true
+	case string:
+		_, err := ParseUUID(value)
+		return value, err == nil
+	}
+	return "", false
+}
+
+// isUUID checks whether the given schema has the uuid logical type.
+func isUUID(schema Schema) bool {
+	_, ok := GetLogicalType(schema).(*UUIDType)
+	return ok
+}
+
+const durationSize = 12
+
+// Duration is an amount of time in months, days and milliseconds, which are independent of each other since the
+// number of days in a month and the number of milliseconds in a day vary.
+type Duration struct {
+	Months       uint32
+	Days         uint32
+	Milliseconds uint32
+}
+
+var durationValueType = reflect.TypeOf(Duration{})
+
+// durationValue extracts a Duration from the given value if it is a Duration or *Duration.
+func durationValue(v reflect.Value) (Duration, bool) {
+	if v.Kind() == reflect.Interface {
+		v = v.Elem()
+	}
+	v = dereference(v)
+	if !v.IsValid() || v.Type() != durationValueType {
+		return Duration{}, false
+	}
+
+	return v.Interface().(Duration), true
+}
+
+// isDuration checks whether the given value may be written as a duration of the given schema.
+func isDuration(schema Schema, v reflect.Value) bool {
+	if _, ok := GetLogicalType(schema).(*DurationType); ok {
+		_, ok = durationValue(v)
+		return ok
+	}
+
+	return false
+}
+
+// encode returns the three little-endian unsigned integers a Duration is stored as.
+func (*DurationType) encode(d Duration) []byte {
+	b := make([]byte, durationSize)
+	binary.LittleEndian.PutUint32(b[0:4], d.Months)
+	binary.LittleEndian.PutUint32(b[4:8], d.Days)
+	binary.LittleEndian.PutUint32(b[8:12], d.Milliseconds)
+	return b
+}
+
+// decode returns the Duration stored in the given bytes.
+func (*DurationType) decode(b []byte) Duration {
+	return Duration{
+		Months:       binary.LittleEndian.Uint32(b[0:4]),
+		Days:         binary.LittleEndian.Uint32(b[4:8]),
+		Milliseconds: binary.LittleEndian.Uint32(b[8:12]),
+	}
+}
@@ -0,0 +1,272 @@
+package avro
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+	"time"
+)
+
+func TestDecimalSchema(t *testing.T) {
+	schema := MustParseSchema(`{"type": "bytes", "logicalType": "decimal", "precision": 9, "scale": 2}`)
+	assert(t, GetLogicalType(schema), &DecimalType{Precision: 9, Scale: 2})
+	assert(t, MustParseSchema(schema.String()).String(), schema.String())
+
+	fixed := MustParseSchema(`{"type": "fixed", "name": "money", "size": 4, "logicalType": "decimal", "precision": 9}`)
+	assert(t, GetLogicalType(fixed), &DecimalType{Precision: 9, Scale: 0})
+	assert(t, GetLogicalType(MustParseSchema(fixed.String())), &DecimalType{Precision: 9, Scale: 0})
+
+	// unknown logical types are ignored
+	assert(t, GetLogicalType(MustParseSchema(`{"type": "bytes", "logicalType": "unknown"}`)), nil)
+
+	for _, invalid := range []string{
+		`{"type": "bytes", "logicalType": "decimal"}`,
+		`{"type": "bytes", "logicalType": "decimal", "precision": 0}`,
+		`{"type": "bytes", "logicalType": "decimal", "precision": 2.5}`,
+		`{"type": "bytes", "logicalType": "decimal", "precision": 4, "scale": 5}`,
+		`{"type": "bytes", "logicalType": "decimal", "precision": 4, "scale": -1}`,
+		`{"type": "fixed", "name": "small", "size": 4, "logicalType": "decimal", "precision": 10}`,
+		`{"type": "bytes", "logicalType": "decimal", "precision": 2147483647}`,
+		`{"type": "fixed", "name": "large", "size": 2147483647, "logicalType": "decimal", "precision": 1001}`,
+	} {
+		// invalid logical types fall back to the underlying type
+		schema, err := ParseSchema(invalid)
+		assert(t, err, nil)
+		assert(t, GetLogicalType(schema), nil)
+	}
+}
+
+func TestDecimalEncoding(t *testing.T) {
+	decimal := &DecimalType{Precision: 10}
+	for _, c := range []struct {
+		value   int64
+		size    int
+		encoded []byte
+	}{
+		{0, 0, []byte{0x00}},
+		{1, 0, []byte{0x01}},
+		{127, 0, []byte{0x7F}},
+		{128, 0, []byte{0x00, 0x80}},
+		{-1, 0, []byte{0xFF}},
+		{-128, 0, []byte{0x80}},
+		{-129, 0, []byte{0xFF, 0x7F}},
+		{-2, 4, []byte{0xFF, 0xFF, 0xFF, 0xFE}},
+		{258, 3, []byte{0x00, 0x01, 0x02}},
+	} {
+		encoded, err := decimal.encode(big.NewRat(c.value, 1), c.size)
+		assert(t, err, nil)
+		assert(t, encoded, c.encoded)
+		assert(t, decimal.decode(encoded).RatString(), big.NewRat(c.value, 1).RatString())
+	}
+
+	if _, err := decimal.encode(big.NewRat(1, 3), 0); err == nil {
+		t.Fatal("Expected an error encoding a decimal exceeding the scale")
+	}
+	if _, err := decimal.encode(big.NewRat(10000000000, 1), 0); err == nil {
+		t.Fatal("Expected an error encoding a decimal exceeding the precision")
+	}
+	if _, err := decimal.encode(big.NewRat(128, 1), 1); err == nil {
+		t.Fatal("Expected an error encoding a decimal exceeding the fixed size")
+	}
+	if _, err := (&DecimalType{Precision: 1 << 30}).encode(big.NewRat(1, 1), 0); err == nil {
+		t.Fatal("Expected an error encoding a decimal with a precision too large")
+	}
+}
+
+type decimalRecord struct {
+	Price  *big.Rat `avro:"price"`
+	Amount big.Rat  `avro:"amount"`
+	Raw    []byte   `avro:"raw"`
+}
+
+func TestDecimalDatum(t *testing.T) {
+	schema := MustParseSchema(`{"type": "record", "name": "Order", "fields": [
+		{"name": "price", "type": {"type": "bytes", "logicalType": "decimal", "precision": 8, "scale": 2}},
+		{"name": "amount", "type": {"type": "fixed", "name": "amount", "size": 8, "logicalType": "decimal", "precision": 18, "scale": 4}},
+		{"name": "raw", "type": {"type": "bytes", "logicalType": "decimal", "precision": 4}}
+	]}`)
+
+	order := &decimalRecord{Price: big.NewRat(-1999, 100), Raw: []byte{0x04, 0xD2}}
+	order.Amount.SetFrac64(12345, 10)
+
+	buf := &bytes.Buffer{}
+	writer := NewSpecificDatumWriter()
+	writer.SetSchema(schema)
+	assert(t, writer.Write(order, NewBinaryEncoder(buf)), nil)
+
+	decoded := &decimalRecord{}
+	reader := NewSpecificDatumReader()
+	reader.SetSchema(schema)
+	assert(t, reader.Read(decoded, NewBinaryDecoder(buf.Bytes())), nil)
+	assert(t, decoded.Price.RatString(), "-1999/100")
+	assert(t, decoded.Amount.RatString(), "2469/2")
+	assert(t, decoded.Raw, []byte{0x04, 0xD2})
+
+	record := NewGenericRecord(schema)
+	genericReader := NewGenericDatumReader()
+	genericReader.SetSchema(schema)
+	assert(t, genericReader.Read(record, NewBinaryDecoder(buf.Bytes())), nil)
+	assert(t, record.Get("price").(*big.Rat).RatString(), "-1999/100")
+	assert(t, record.Get("amount").(*big.Rat).RatString(), "2469/2")
+	assert(t, record.Get("raw").(*big.Rat).RatString(), "1234")
+
+	genericBuf := &bytes.Buffer{}
+	genericWriter := NewGenericDatumWriter()
+	genericWriter.SetSchema(schema)
+	assert(t, genericWriter.Write(record, NewBinaryEncoder(genericBuf)), nil)
+	assert(t, genericBuf.Bytes(), buf.Bytes())
+}
+
+type eventRecord struct {
+	Day       time.Time     `avro:"day"`
+	Alarm     time.Duration `avro:"alarm"`
+	Precise   time.Duration `avro:"precise"`
+	Created   time.Time     `avro:"created"`
+	Updated   time.Time     `avro:"updated"`
+	Local     time.Time     `avro:"local"`
+	LocalFine time.Time     `avro:"local_fine"`
+	Raw       int64         `avro:"raw"`
+}
+
+const eventSchemaRaw = `{"type": "record", "name": "Event", "fields": [
+	{"name": "day", "type": {"type": "int", "logicalType": "date"}},
+	{"name": "alarm", "type": {"type": "int", "logicalType": "time-millis"}},
+	{"name": "precise", "type": {"type": "long", "logicalType": "time-micros"}},
+	{"name": "created", "type": {"type": "long", "logicalType": "timestamp-millis"}},
+	{"name": "updated", "type": {"type": "long", "logicalType": "timestamp-micros"}},
+	{"name": "local", "type": {"type": "long", "logicalType": "local-timestamp-millis"}},
+	{"name": "local_fine", "type": {"type": "long", "logicalType": "local-timestamp-micros"}},
+	{"name": "raw", "type": {"type": "long", "logicalType": "timestamp-millis"}}
+]}`
+
+func TestTemporalSchema(t *testing.T) {
+	schema := MustParseSchema(eventSchemaRaw).(*RecordSchema)
+	assert(t, GetLogicalType(schema.Fields[0].Type), TemporalType(DateLogicalType))
+	assert(t, GetLogicalType(schema.Fields[6].Type), TemporalType(LocalTimestampMicrosLogicalType))
+	assert(t, MustParseSchema(schema.String()).String(), schema.String())
+
+	// logical types not matching the underlying type are ignored
+	assert(t, GetLogicalType(MustParseSchema(`{"type": "long", "logicalType": "date"}`)), nil)
+	assert(t, GetLogicalType(MustParseSchema(`{"type": "int", "logicalType": "timestamp-millis"}`)), nil)
+}
+
+func TestTemporalDatum(t *testing.T) {
+	schema := MustParseSchema(eventSchemaRaw)
+	zone := time.FixedZone("UTC+3", 3*60*60)
+	event := &eventRecord{
+		Day:       time.Date(2017, 3, 1, 1, 30, 0, 0, zone),
+		Alarm:     7*time.Hour + 30*time.Minute + 1500*time.Microsecond,
+		Precise:   time.Second + 1500*time.Nanosecond,
+		Created:   time.Date(1969, 12, 31, 23, 59, 59, 999500000, time.UTC),
+		Updated:   time.Date(2017, 3, 1, 1, 30, 0, 123456789, zone),
+		Local:     time.Date(2017, 3, 1, 1, 30, 0, 0, zone),
+		LocalFine: time.Date(2017, 3, 1, 1, 30, 0, 1000, time.UTC),
+		Raw:       1000,
+	}
+
+	buf := &bytes.Buffer{}
+	writer := NewSpecificDatumWriter()
+	writer.SetSchema(schema)
+	assert(t, writer.Write(event, NewBinaryEncoder(buf)), nil)
+
+	decoded := &eventRecord{}
+	reader := NewSpecificDatumReader()
+	reader.SetSchema(schema)
+	assert(t, reader.Read(decoded, NewBinaryDecoder(buf.Bytes())), nil)
+	assert(t, decoded.Day, time.Date(2017, 3, 1, 0, 0, 0, 0, time.UTC))
+	assert(t, decoded.Alarm, 7*time.Hour+30*time.Minute+time.Millisecond)
+	assert(t, decoded.Precise, time.Second+time.Microsecond)
+	assert(t, decoded.Created, time.Date(1969, 12, 31, 23, 59, 59, 999000000, time.UTC))
+	assert(t, decoded.Updated, time.Date(2017, 2, 28, 22, 30, 0, 123456000, time.UTC))
+	assert(t, decoded.Local, time.Date(2017, 3, 1, 1, 30, 0, 0, time.Local))
+	assert(t, decoded.LocalFine, time.Date(2017, 3, 1, 1, 30, 0, 1000, time.Local))
+	assert(t, decoded.Raw, int64(1000))
+
+	record := NewGenericRecord(schema)
+	genericReader := NewGenericDatumReader()
+	genericReader.SetSchema(schema)
+	assert(t, genericReader.Read(record, NewBinaryDecoder(buf.Bytes())), nil)
+	assert(t, record.Get("day"), int32(17226))
+	assert(t, record.Get("created"), int64(-1))
+
+	genericReader.SetTimeValues(true)
+	assert(t, genericReader.Read(record, NewBinaryDecoder(buf.Bytes())), nil)
+	assert(t, record.Get("day"), decoded.Day)
+	assert(t, record.Get("alarm"), decoded.Alarm)
+	assert(t, record.Get("updated"), decoded.Updated)
+	assert(t, record.Get("raw"), time.Date(1970, 1, 1, 0, 0, 1, 0, time.UTC))
+
+	genericBuf := &bytes.Buffer{}
+	genericWriter := NewGenericDatumWriter()
+	genericWriter.SetSchema(schema)
+	assert(t, genericWriter.Write(record, NewBinaryEncoder(genericBuf)), nil)
+	assert(t, genericBuf.Bytes(), buf.Bytes())
+}
+
+func TestUUID(t *testing.T) {
+	u, err := ParseUUID("123E4567-e89b-12d3-a456-426655440000")
+	assert(t, err, nil)
+	assert(t, u[0], byte(0x12))
+	assert(t, u.String(), "123e4567-e89b-12d3-a456-426655440000")
+
+	for _, invalid := range []string{"", "123e4567e89b12d3a456426655440000", "123e4567-e89b-12d3-a456-42665544000g"} {
+		if _, err := ParseUUID(invalid); err == nil {
+			t.Fatalf("Expected an error parsing %q", invalid)
+		}
+	}
+}
+
+type slaRecord struct {
+	ID       UUID     `avro:"id"`
+	Parent   string   `avro:"parent"`
+	Deadline Duration `avro:"deadline"`
+}
+
+func TestUUIDAndDurationDatum(t *testing.T) {
+	schema := MustParseSchema(`{"type": "record", "name": "SLA", "fields": [
+		{"name": "id", "type": {"type": "string", "logicalType": "uuid"}},
+		{"name": "parent", "type": {"type": "string", "logicalType": "uuid"}},
+		{"name": "deadline", "type": {"type": "fixed", "name": "deadline", "size": 12, "logicalType": "duration"}}
+	]}`).(*RecordSchema)
+	assert(t, GetLogicalType(schema.Fields[0].Type), &UUIDType{})
+	assert(t, GetLogicalType(schema.Fields[2].Type), &DurationType{})
+	assert(t, MustParseSchema(schema.String()).String(), schema.String())
+	assert(t, GetLogicalType(MustParseSchema(`{"type": "fixed", "name": "short", "size": 4, "logicalType": "duration"}`)), nil)
+
+	id, _ := ParseUUID("123e4567-e89b-12d3-a456-426655440000")
+	sla := &slaRecord{ID: id, Parent: "00000000-0000-0000-0000-000000000001", Deadline: Duration{Months: 1, Days: 2, Milliseconds: 300}}
+
+	buf := &bytes.Buffer{}
+	writer := NewSpecificDatumWriter()
+	writer.SetSchema(schema)
+	assert(t, writer.Write(sla, NewBinaryEncoder(buf)), nil)
+	assert(t, buf.Bytes()[buf.Len()-12:], []byte{1, 0, 0, 0, 2, 0, 0, 0, 0x2C, 1, 0, 0})
+
+	decoded := &slaRecord{}
+	reader := NewSpecificDatumReader()
+	reader.SetSchema(schema)
+	assert(t, reader.Read(decoded, NewBinaryDecoder(buf.Bytes())), nil)
+	assert(t, decoded, sla)
+
+	record := NewGenericRecord(schema)
+	genericReader := NewGenericDatumReader()
+	genericReader.SetSchema(schema)
+	assert(t, genericReader.Read(record, NewBinaryDecoder(buf.Bytes())), nil)
+	assert(t, record.Get("id"), "123e4567-e89b-12d3-a456-426655440000")
+	assert(t, record.Get("deadline"), sla.Deadline)
+
+	genericBuf := &bytes.Buffer{}
+	genericWriter := NewGenericDatumWriter()
+	genericWriter.SetSchema(schema)
+	assert(t, genericWriter.Write(record, NewBinaryEncoder(genericBuf)), nil)
+	assert(t, genericBuf.Bytes(), buf.Bytes())
+
+	sla.Parent = "not a uuid"
+	if err := writer.Write(sla, NewBinaryEncoder(&bytes.Buffer{})); err == nil {
+		t.Fatal("Expected an error writing an invalid uuid")
+	}
+	record.Set("parent", "not a uuid")
+	if err := genericWriter.Write(record, NewBinaryEncoder(&bytes.Buffer{})); err == nil {
+		t.Fatal("Expected an error writing an invalid uuid")
+	}
+}
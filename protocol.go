@@ -0,0 +1,265 @@
+package avro
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strings"
+)
+
+// Avro protocols, which describe RPC interfaces by their named schemas and messages.
+// Spec: https://avro.apache.org/docs/current/spec.html#Protocol+Declaration
+
+const (
+	protocolNameField     = "protocol"
+	protocolTypesField    = "types"
+	protocolMessagesField = "messages"
+	messageRequestField   = "request"
+	messageResponseField  = "response"
+	messageErrorsField    = "errors"
+	messageOneWayField    = "one-way"
+)
+
+// Protocol represents an Avro protocol.
+type Protocol struct {
+	Name       string
+	Namespace  string
+	Doc        string
+	Properties map[string]interface{}
+
+	// Types are the named schemas of this protocol in the order they are declared.
+	Types []Schema
+
+	// Messages are the messages of this protocol by their names.
+	Messages map[string]*Message
+}
+
+// String returns a JSON representation of Protocol.
+func (p *Protocol) String() string {
+	bytes, err := json.MarshalIndent(p, "", "    ")
+	if err != nil {
+		panic(err)
+	}
+
+	return string(bytes)
+}
+
+// Prop gets a custom non-reserved property from this protocol and a bool representing if it exists.
+func (p *Protocol) Prop(key string) (interface{}, bool) {
+	if p.Properties != nil {
+		if prop, ok := p.Properties[key]; ok {
+			return prop, true
+		}
+	}
+
+	return nil, false
+}
+
+// MarshalJSON serializes the given protocol as JSON.
+func (p *Protocol) MarshalJSON() ([]byte, error) {
+	types := p.Types
+	if types == nil {
+		types = make([]Schema, 0)
+	}
+	messages := p.Messages
+	if messages == nil {
+		messages = make(map[string]*Message)
+	}
+
+	v := propertiesJSON(p.Properties)
+	v[protocolNameField] = p.Name
+	if p.Namespace != "" {
+		v[schemaNamespaceField] = p.Namespace
+	}
+	if p.Doc != "" {
+		v[schemaDocField] = p.Doc
+	}
+	v[protocolTypesField] = types
+	v[protocolMessagesField] = messages
+	return json.Marshal(v)
+}
+
+// Message represents a message of an Avro protocol.
+type Message struct {
+	Name       string
+	Doc        string
+	Properties map[string]interface{}
+
+	// Request are the parameters of this message.
+	Request []*SchemaField
+
+	// Response is the schema of the response, a *NullSchema if there is none.
+	Response Schema
+
+	// Errors are the schemas of the errors this message may respond with besides a string.
+	Errors []Schema
+
+	// OneWay tells whether this message expects no response at all, not even an error.
+	OneWay bool
+}
+
+// Prop gets a custom non-reserved property from this message and a bool representing if it exists.
+func (m *Message) Prop(key string) (interface{}, bool) {
+	if m.Properties != nil {
+		if prop, ok := m.Properties[key]; ok {
+			return prop, true
+		}
+	}
+
+	return nil, false
+}
+
+// MarshalJSON serializes the given message as JSON.
+func (m *Message) MarshalJSON() ([]byte, error) {
+	request := m.Request
+	if request == nil {
+		request = make([]*SchemaField, 0)
+	}
+
+	v := propertiesJSON(m.Properties)
+	if m.Doc != "" {
+		v[schemaDocField] = m.Doc
+	}
+	v[messageRequestField] = request
+	v[messageResponseField] = m.Response
+	if len(m.Errors) > 0 {
+		v[messageErrorsField] = m.Errors
+	}
+	if m.OneWay {
+		v[messageOneWayField] = true
+	}
+	return json.Marshal(v)
+}
+
+// ParseProtocolFile parses a given protocol file.
+// May return an error if protocol is not parsable or file does not exist.
+func ParseProtocolFile(file string) (*Protocol, error) {
+	fileContents, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseProtocol(string(fileContents))
+}
+
+// ParseProtocol parses a given protocol in JSON without provided schemas to reuse.
+// May return an error if protocol is not parsable or has insufficient information about any type.
+func ParseProtocol(rawProtocol string) (*Protocol, error) {
+	return ParseProtocolWithRegistry(rawProtocol, make(map[string]Schema))
+}
+
+// ParseProtocolWithRegistry parses a given protocol in JSON using the provided registry for type lookup.
+// Registry will be filled up during parsing.
+// May return an error if protocol is not parsable or has insufficient information about any type.
+func ParseProtocolWithRegistry(rawProtocol string, schemas map[string]Schema) (*Protocol, error) {
+	var v map[string]interface{}
+	if err := json.Unmarshal([]byte(rawProtocol), &v); err != nil {
+		return nil, err
+	}
+
+	name, ok := v[protocolNameField].(string)
+	if !ok {
+		return nil, fmt.Errorf("Protocol name missing")
+	}
+	protocol := &Protocol{Name: name, Messages: make(map[string]*Message)}
+	setOptionalField(&protocol.Namespace, v, schemaNamespaceField)
+	setOptionalField(&protocol.Doc, v, schemaDocField)
+	protocol.Properties = getPropertiesExcept(v, protocolNameField, schemaNamespaceField, schemaDocField,
+		protocolTypesField, protocolMessagesField)
+
+	types, _ := v[protocolTypesField].([]interface{})
+	protocol.Types = make([]Schema, len(types))
+	for i := range types {
+		// named schemas know the namespace they inherit from the protocol, like the ones declared in IDL
+		if t, ok := types[i].(map[string]interface{}); ok && protocol.Namespace != "" {
+			name, _ := t[schemaNameField].(string)
+			if _, exists := t[schemaNamespaceField]; !exists && !strings.ContainsRune(name, '.') {
+				t[schemaNamespaceField] = protocol.Namespace
+			}
+		}
+		schema, err := schemaByType(types[i], schemas, protocol.Namespace)
+		if err != nil {
+			return nil, err
+		}
+		protocol.Types[i] = schema
+	}
+
+	messages, _ := v[protocolMessagesField].(map[string]interface{})
+	for name, m := range messages {
+		message, err := parseMessage(name, m, schemas, protocol.Namespace)
+		if err != nil {
+			return nil, err
+		}
+		protocol.Messages[name] = message
+	}
+
+	return protocol, nil
+}
+
+func parseMessage(name string, i interface{}, registry map[string]Schema, namespace string) (*Message, error) {
+	v, ok := i.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("Invalid message %s", name)
+	}
+	request, ok := v[messageRequestField].([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("Request of message %s missing", name)
+	}
+	response, ok := v[messageResponseField]
+	if !ok {
+		return nil, fmt.Errorf("Response of message %s missing", name)
+	}
+
+	message := &Message{Name: name, Request: make([]*SchemaField, len(request))}
+	setOptionalField(&message.Doc, v, schemaDocField)
+	message.Properties = getPropertiesExcept(v, schemaDocField, messageRequestField, messageResponseField,
+		messageErrorsField, messageOneWayField)
+	for i := range request {
+		field, err := parseSchemaField(request[i], registry, namespace)
+		if err != nil {
+			return nil, err
+		}
+		message.Request[i] = field
+	}
+
+	var err error
+	if message.Response, err = schemaByType(response, registry, namespace); err != nil {
+		return nil, err
+	}
+	errors, _ := v[messageErrorsField].([]interface{})
+	for _, e := range errors {
+		schema, err := schemaByType(e, registry, namespace)
+		if err != nil {
+			return nil, err
+		}
+		message.Errors = append(message.Errors, schema)
+	}
+
+	message.OneWay, _ = v[messageOneWayField].(bool)
+	if message.OneWay && (message.Response.Type() != Null || len(message.Errors) > 0) {
+		return nil, fmt.Errorf("One-way message %s must have a null response and no errors", name)
+	}
+
+	return message, nil
+}
+
+// gets custom properties of protocols and messages, which reserve other names than schemas
+func getPropertiesExcept(v map[string]interface{}, reserved ...string) map[string]interface{} {
+	props := make(map[string]interface{})
+	for name, value := range v {
+		props[name] = value
+	}
+	for _, name := range reserved {
+		delete(props, name)
+	}
+	return props
+}
+
+// copies custom properties of protocols and messages into a new JSON object for the reserved fields to be added to
+func propertiesJSON(props map[string]interface{}) map[string]interface{} {
+	v := make(map[string]interface{}, len(props))
+	for name, value := range props {
+		v[name] = value
+	}
+	return v
+}
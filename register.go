@@ -58,10 +58,13 @@ type SchemaMetadata struct {
 type CompatibilityLevel string
 
 const (
-	BackwardCompatibilityLevel CompatibilityLevel = "BACKWARD"
-	ForwardCompatibilityLevel  CompatibilityLevel = "FORWARD"
-	FullCompatibilityLevel     CompatibilityLevel = "FULL"
-	NoneCompatibilityLevel     CompatibilityLevel = "NONE"
+	BackwardCompatibilityLevel           CompatibilityLevel = "BACKWARD"
+	BackwardTransitiveCompatibilityLevel CompatibilityLevel = "BACKWARD_TRANSITIVE"
+	ForwardCompatibilityLevel            CompatibilityLevel = "FORWARD"
+	ForwardTransitiveCompatibilityLevel  CompatibilityLevel = "FORWARD_TRANSITIVE"
+	FullCompatibilityLevel               CompatibilityLevel = "FULL"
+	FullTransitiveCompatibilityLevel     CompatibilityLevel = "FULL_TRANSITIVE"
+	NoneCompatibilityLevel               CompatibilityLevel = "NONE"
 )
 
 const (
@@ -0,0 +1,715 @@
+package avro
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"strings"
+)
+
+// Support for reading data written with one schema (the writer's schema) as another schema (the reader's schema).
+// Spec: https://avro.apache.org/docs/current/spec.html#Schema+Resolution
+//
+// A resolution is compiled once for a pair of schemas into a resolveFunc that reads a datum encoded with the writer's
+// schema and encodes it again as a datum of the reader's schema, so any DatumReader can decode the result.
+
+// resolveFunc reads a single value from dec and writes its resolved form to enc.
+// Must be safe for concurrent use.
+type resolveFunc func(dec Decoder, enc Encoder) error
+
+var discardEncoder = NewBinaryEncoder(ioutil.Discard)
+
+type schemaResolver struct {
+	// compiled record resolutions, these allow recursive schemas
+	records map[[2]*RecordSchema]*resolveFunc
+}
+
+// newResolveFunc compiles the resolution of data written with the writer schema to the reader schema.
+// Returns an error if the schemas do not match according to the resolution rules.
+func newResolveFunc(writer, reader Schema) (resolveFunc, error) {
+	resolver := &schemaResolver{records: make(map[[2]*RecordSchema]*resolveFunc)}
+	return resolver.compile(writer, reader)
+}
+
+// datumResolution resolves data written with a writer's schema to the schema of a DatumReader before it is decoded.
+type datumResolution struct {
+	writer  Schema
+	resolve resolveFunc
+	err     error
+}
+
+// compile resolves the writer's schema, if any, with the given reader's schema.
+func (r *datumResolution) compile(reader Schema) error {
+	r.resolve, r.err = nil, nil
+	if r.writer != nil && reader != nil {
+		r.resolve, r.err = newResolveFunc(r.writer, reader)
+	}
+	return r.err
+}
+
+// decoder reads a datum from dec and returns a Decoder of its resolved form, or dec if there is nothing to resolve.
+func (r *datumResolution) decoder(dec Decoder) (Decoder, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	if r.resolve == nil {
+		return dec, nil
+	}
+
+	buf := &bytes.Buffer{}
+	if err := r.resolve(dec, NewBinaryEncoder(buf)); err != nil {
+		return nil, err
+	}
+	return NewBinaryDecoder(buf.Bytes()), nil
+}
+
+func (r *schemaResolver) compile(writer, reader Schema) (resolveFunc, error) {
+	writer, reader = actualSchema(writer), actualSchema(reader)
+	if writer.Type() == Union {
+		return r.compileWriterUnion(writer.(*UnionSchema), reader)
+	}
+	if reader.Type() == Union {
+		return r.compileReaderUnion(writer, reader.(*UnionSchema))
+	}
+
+	switch reader.Type() {
+	case Null:
+		if writer.Type() == Null {
+			return func(dec Decoder, enc Encoder) error { return nil }, nil
+		}
+	case Boolean:
+		if writer.Type() == Boolean {
+			return func(dec Decoder, enc Encoder) error {
+				value, err := dec.ReadBoolean()
+				enc.WriteBoolean(value)
+				return err
+			}, nil
+		}
+	case Int, Long, Float, Double:
+		if promote := numericPromotion(writer.Type(), reader.Type()); promote != nil {
+			return promote, nil
+		}
+	case String, Bytes:
+		// strings and bytes have the same binary encoding, so they are promoted to each other as they are
+		if writer.Type() == String || writer.Type() == Bytes {
+			return func(dec Decoder, enc Encoder) error {
+				value, err := dec.ReadBytes()
+				enc.WriteBytes(value)
+				return err
+			}, nil
+		}
+	case Fixed:
+		if writer.Type() == Fixed && namesMatch(writer, reader) {
+			return r.compileFixed(writer.(*FixedSchema), reader.(*FixedSchema))
+		}
+	case Enum:
+		if writer.Type() == Enum && namesMatch(writer, reader) {
+			return r.compileEnum(writer.(*EnumSchema), reader.(*EnumSchema))
+		}
+	case Array:
+		if writer.Type() == Array {
+			return r.compileArray(writer.(*ArraySchema), reader.(*ArraySchema))
+		}
+	case Map:
+		if writer.Type() == Map {
+			return r.compileMap(writer.(*MapSchema), reader.(*MapSchema))
+		}
+	case Record:
+		if writer.Type() == Record && namesMatch(writer, reader) {
+			return r.compileRecord(writer.(*RecordSchema), reader.(*RecordSchema))
+		}
+	}
+
+	return nil, fmt.Errorf("Cannot resolve writer schema %s with reader schema %s", writer.GetName(), reader.GetName())
+}
+
+// skip compiles reading a value of the given schema without writing anything.
+func (r *schemaResolver) skip(schema Schema) (resolveFunc, error) {
+	read, err := r.compile(schema, schema)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(dec Decoder, enc Encoder) error {
+		return read(dec, discardEncoder)
+	}, nil
+}
+
+func numericPromotion(writer, reader int) resolveFunc {
+	switch reader {
+	case Int, Long, Float, Double:
+	default:
+		return nil
+	}
+
+	switch writer {
+	case Int:
+		return func(dec Decoder, enc Encoder) error {
+			value, err := dec.ReadInt()
+			writeNumber(enc, reader, float64(value), int64(value))
+			return err
+		}
+	case Long:
+		if reader != Int {
+			return func(dec Decoder, enc Encoder) error {
+				value, err := dec.ReadLong()
+				writeNumber(enc, reader, float64(value), value)
+				return err
+			}
+		}
+	case Float:
+		if reader == Float || reader == Double {
+			return func(dec Decoder, enc Encoder) error {
+				value, err := dec.ReadFloat()
+				writeNumber(enc, reader, float64(value), 0)
+				return err
+			}
+		}
+	case Double:
+		if reader == Double {
+			return func(dec Decoder, enc Encoder) error {
+				value, err := dec.ReadDouble()
+				enc.WriteDouble(value)
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// promotable checks whether values of the writer's primitive type may be read as a different reader's type.
+func promotable(writer, reader int) bool {
+	if writer == reader {
+		return false
+	}
+	if (writer == String || writer == Bytes) && (reader == String || reader == Bytes) {
+		return true
+	}
+
+	return numericPromotion(writer, reader) != nil
+}
+
+func writeNumber(enc Encoder, schemaType int, value float64, integer int64) {
+	switch schemaType {
+	case Int:
+		enc.WriteInt(int32(integer))
+	case Long:
+		enc.WriteLong(integer)
+	case Float:
+		enc.WriteFloat(float32(value))
+	case Double:
+		enc.WriteDouble(value)
+	}
+}
+
+func (r *schemaResolver) compileFixed(writer, reader *FixedSchema) (resolveFunc, error) {
+	if writer.Size != reader.Size {
+		return nil, fmt.Errorf("Cannot resolve fixed %s of size %d with size %d", reader.Name, writer.Size, reader.Size)
+	}
+
+	size := writer.Size
+	return func(dec Decoder, enc Encoder) error {
+		fixed := make([]byte, size)
+		err := dec.ReadFixed(fixed)
+		enc.WriteRaw(fixed)
+		return err
+	}, nil
+}
+
+func (r *schemaResolver) compileEnum(writer, reader *EnumSchema) (resolveFunc, error) {
+	readerSymbols := make(map[string]int32, len(reader.Symbols))
+	for i, symbol := range reader.Symbols {
+		readerSymbols[symbol] = int32(i)
+	}
+
+	// index of each writer's symbol in the reader's symbols, or of the reader's default if the reader does not know
+	// it, or -1 if there is no default either
+	indexes := make([]int32, len(writer.Symbols))
+	for i, symbol := range writer.Symbols {
+		index, ok := readerSymbols[symbol]
+		if !ok {
+			if index, ok = readerSymbols[reader.Default]; !ok {
+				index = -1
+			}
+		}
+		indexes[i] = index
+	}
+
+	return func(dec Decoder, enc Encoder) error {
+		index, err := dec.ReadEnum()
+		if err != nil {
+			return err
+		}
+		if index < 0 || int(index) >= len(indexes) {
+			return fmt.Errorf("Invalid enum index %d of enum %s", index, writer.Name)
+		}
+		if indexes[index] < 0 {
+			return fmt.Errorf("Symbol %s of enum %s is unknown to the reader's schema", writer.Symbols[index], reader.Name)
+		}
+		enc.WriteInt(indexes[index])
+		return nil
+	}, nil
+}
+
+func (r *schemaResolver) compileArray(writer, reader *ArraySchema) (resolveFunc, error) {
+	items, err := r.compile(writer.Items, reader.Items)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(dec Decoder, enc Encoder) error {
+		count, err := dec.ReadArrayStart()
+		for ; err == nil && count > 0; count, err = dec.ArrayNext() {
+			enc.WriteArrayStart(count)
+			for i := int64(0); i < count; i++ {
+				if err = items(dec, enc); err != nil {
+					return err
+				}
+			}
+		}
+		enc.WriteArrayNext(0)
+		return err
+	}, nil
+}
+
+func (r *schemaResolver) compileMap(writer, reader *MapSchema) (resolveFunc, error) {
+	values, err := r.compile(writer.Values, reader.Values)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(dec Decoder, enc Encoder) error {
+		count, err := dec.ReadMapStart()
+		for ; err == nil && count > 0; count, err = dec.MapNext() {
+			enc.WriteMapStart(count)
+			for i := int64(0); i < count; i++ {
+				key, err := dec.ReadString()
+				if err != nil {
+					return err
+				}
+				enc.WriteString(key)
+				if err = values(dec, enc); err != nil {
+					return err
+				}
+			}
+		}
+		enc.WriteMapNext(0)
+		return err
+	}, nil
+}
+
+func (r *schemaResolver) compileWriterUnion(writer *UnionSchema, reader Schema) (resolveFunc, error) {
+	// branches of the writer's union that do not match the reader's schema only fail when they are actually read
+	branches := make([]resolveFunc, len(writer.Types))
+	for i, branch := range writer.Types {
+		resolve, err := r.compile(branch, reader)
+		if err != nil {
+			message := err.Error()
+			resolve = func(dec Decoder, enc Encoder) error {
+				return fmt.Errorf("%s", message)
+			}
+		}
+		branches[i] = resolve
+	}
+
+	return func(dec Decoder, enc Encoder) error {
+		index, err := dec.ReadInt()
+		if err != nil {
+			return err
+		}
+		if index < 0 || int(index) >= len(branches) {
+			return UnionTypeOverflow
+		}
+		return branches[index](dec, enc)
+	}, nil
+}
+
+// unionBranch returns the index of the branch of the reader's union the given writer's schema is resolved with, or -1
+// if there is none.
+func unionBranch(writer Schema, reader *UnionSchema) int {
+	// prefer a branch of the same type before trying promotions
+	for i, branch := range reader.Types {
+		branch = actualSchema(branch)
+		if branch.Type() == writer.Type() && (!isNamed(branch) || namesMatch(writer, branch)) {
+			return i
+		}
+	}
+	for i, branch := range reader.Types {
+		if promotable(writer.Type(), actualSchema(branch).Type()) {
+			return i
+		}
+	}
+
+	return -1
+}
+
+func (r *schemaResolver) compileReaderUnion(writer Schema, reader *UnionSchema) (resolveFunc, error) {
+	index := unionBranch(writer, reader)
+	if index < 0 {
+		return nil, fmt.Errorf("Cannot resolve writer schema %s with any branch of reader union", writer.GetName())
+	}
+
+	resolve, err := r.compile(writer, reader.Types[index])
+	if err != nil {
+		return nil, err
+	}
+	return func(dec Decoder, enc Encoder) error {
+		enc.WriteInt(int32(index))
+		return resolve(dec, enc)
+	}, nil
+}
+
+type fieldResolution struct {
+	resolve resolveFunc
+	// index of the matching reader field or -1 if the writer's field is skipped
+	target int
+}
+
+func (r *schemaResolver) compileRecord(writer, reader *RecordSchema) (resolveFunc, error) {
+	key := [2]*RecordSchema{writer, reader}
+	if compiled, ok := r.records[key]; ok {
+		// recursive reference, the function is filled in once compiled
+		return func(dec Decoder, enc Encoder) error {
+			return (*compiled)(dec, enc)
+		}, nil
+	}
+	compiled := new(resolveFunc)
+	r.records[key] = compiled
+
+	targets := matchFields(writer, reader)
+
+	fields := make([]fieldResolution, len(writer.Fields))
+	found := make([]bool, len(reader.Fields))
+	inOrder := true
+	last := -1
+	for i, field := range writer.Fields {
+		target := targets[i]
+		if target < 0 {
+			skip, err := r.skip(field.Type)
+			if err != nil {
+				return nil, err
+			}
+			fields[i] = fieldResolution{resolve: skip, target: -1}
+			continue
+		}
+
+		resolve, err := r.compile(field.Type, reader.Fields[target].Type)
+		if err != nil {
+			return nil, fmt.Errorf("Cannot resolve field %s of record %s: %v", field.Name, reader.Name, err)
+		}
+		fields[i] = fieldResolution{resolve: resolve, target: target}
+		found[target] = true
+		inOrder = inOrder && target > last
+		last = target
+	}
+
+	// encode defaults of reader's fields the writer does not know about
+	defaults := make([][]byte, len(reader.Fields))
+	for i, field := range reader.Fields {
+		if found[i] {
+			continue
+		}
+		buf := &bytes.Buffer{}
+		if err := encodeDefault(NewBinaryEncoder(buf), field); err != nil {
+			return nil, fmt.Errorf("Cannot resolve record %s: %v", reader.Name, err)
+		}
+		defaults[i] = buf.Bytes()
+	}
+
+	if inOrder {
+		*compiled = func(dec Decoder, enc Encoder) error {
+			next := 0
+			for i := range fields {
+				field := &fields[i]
+				for ; next < field.target; next++ {
+					enc.WriteRaw(defaults[next])
+				}
+				if err := field.resolve(dec, enc); err != nil {
+					return err
+				}
+				if field.target >= 0 {
+					next = field.target + 1
+				}
+			}
+			for ; next < len(defaults); next++ {
+				enc.WriteRaw(defaults[next])
+			}
+			return nil
+		}
+	} else {
+		*compiled = func(dec Decoder, enc Encoder) error {
+			// fields come in a different order, so buffer them and write in reader's order
+			buf := &bytes.Buffer{}
+			bufEnc := NewBinaryEncoder(buf)
+			values := make([][2]int, len(defaults))
+			for i := range fields {
+				field := &fields[i]
+				if field.target < 0 {
+					if err := field.resolve(dec, bufEnc); err != nil {
+						return err
+					}
+					continue
+				}
+				start := buf.Len()
+				if err := field.resolve(dec, bufEnc); err != nil {
+					return err
+				}
+				values[field.target] = [2]int{start, buf.Len()}
+			}
+			for i := range defaults {
+				if found[i] {
+					enc.WriteRaw(buf.Bytes()[values[i][0]:values[i][1]])
+				} else {
+					enc.WriteRaw(defaults[i])
+				}
+			}
+			return nil
+		}
+	}
+
+	return *compiled, nil
+}
+
+// encodeDefault writes the default value of the given field in binary form.
+func encodeDefault(enc Encoder, field *SchemaField) error {
+	if !field.HasDefault && field.Default == nil {
+		return fmt.Errorf("Field %s has no default value", field.Name)
+	}
+	if err := encodeJSONValue(enc, field.Type, field.Default); err != nil {
+		return fmt.Errorf("Invalid default value for field %s: %v", field.Name, err)
+	}
+
+	return nil
+}
+
+// encodeJSONValue writes a value parsed from JSON, like default values, in binary form according to the given schema.
+func encodeJSONValue(enc Encoder, schema Schema, value interface{}) error {
+	schema = actualSchema(schema)
+	switch schema.Type() {
+	case Null:
+		if value != nil {
+			return fmt.Errorf("%v is not null", value)
+		}
+	case Boolean:
+		b, ok := value.(bool)
+		if !ok {
+			return fmt.Errorf("%v is not a boolean", value)
+		}
+		enc.WriteBoolean(b)
+	case Int, Long, Float, Double:
+		number, ok := jsonNumber(value)
+		if !ok {
+			return fmt.Errorf("%v is not a number", value)
+		}
+		switch schema.Type() {
+		case Int:
+			if number != math.Trunc(number) || number < math.MinInt32 || number > math.MaxInt32 {
+				return fmt.Errorf("%v is not an int", value)
+			}
+		case Long:
+			if number != math.Trunc(number) {
+				return fmt.Errorf("%v is not a long", value)
+			}
+		}
+		writeNumber(enc, schema.Type(), number, int64(number))
+	case String:
+		s, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("%v is not a string", value)
+		}
+		enc.WriteString(s)
+	case Bytes:
+		b, err := jsonBytes(value)
+		if err != nil {
+			return err
+		}
+		enc.WriteBytes(b)
+	case Fixed:
+		b, err := jsonBytes(value)
+		if err != nil {
+			return err
+		}
+		if len(b) != schema.(*FixedSchema).Size {
+			return fmt.Errorf("%v does not have the size of fixed %s", value, schema.GetName())
+		}
+		enc.WriteRaw(b)
+	case Enum:
+		symbol, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("%v is not an enum symbol", value)
+		}
+		for i, s := range schema.(*EnumSchema).Symbols {
+			if s == symbol {
+				enc.WriteInt(int32(i))
+				return nil
+			}
+		}
+		return fmt.Errorf("%s is not a symbol of enum %s", symbol, schema.GetName())
+	case Array:
+		items, ok := value.([]interface{})
+		if !ok {
+			return fmt.Errorf("%v is not an array", value)
+		}
+		if len(items) > 0 {
+			enc.WriteArrayStart(int64(len(items)))
+			for _, item := range items {
+				if err := encodeJSONValue(enc, schema.(*ArraySchema).Items, item); err != nil {
+					return err
+				}
+			}
+		}
+		enc.WriteArrayNext(0)
+	case Map:
+		entries, ok := value.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%v is not a map", value)
+		}
+		if len(entries) > 0 {
+			enc.WriteMapStart(int64(len(entries)))
+			for key, entry := range entries {
+				enc.WriteString(key)
+				if err := encodeJSONValue(enc, schema.(*MapSchema).Values, entry); err != nil {
+					return err
+				}
+			}
+		}
+		enc.WriteMapNext(0)
+	case Union:
+		// the default value of a union corresponds to its first branch
+		enc.WriteInt(0)
+		return encodeJSONValue(enc, schema.(*UnionSchema).Types[0], value)
+	case Record:
+		fields, ok := value.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%v is not a record", value)
+		}
+		for _, field := range schema.(*RecordSchema).Fields {
+			if fieldValue, ok := fields[field.Name]; ok {
+				if err := encodeJSONValue(enc, field.Type, fieldValue); err != nil {
+					return err
+				}
+			} else if err := encodeDefault(enc, field); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("Unsupported schema type: %d", schema.Type())
+	}
+
+	return nil
+}
+
+func jsonNumber(value interface{}) (float64, bool) {
+	switch number := value.(type) {
+	case float64:
+		return number, true
+	case float32:
+		return float64(number), true
+	case int32:
+		return float64(number), true
+	case int64:
+		return float64(number), true
+	case int:
+		return float64(number), true
+	}
+
+	return 0, false
+}
+
+// jsonBytes converts a JSON string to bytes, each code point of the string representing a single byte.
+func jsonBytes(value interface{}) ([]byte, error) {
+	s, ok := value.(string)
+	if !ok {
+		return nil, fmt.Errorf("%v is not a bytes string", value)
+	}
+
+	b := make([]byte, 0, len(s))
+	for _, r := range s {
+		if r > 0xFF {
+			return nil, fmt.Errorf("%q is not a bytes string", s)
+		}
+		b = append(b, byte(r))
+	}
+	return b, nil
+}
+
+// actualSchema unwraps references to named schemas and prepared schemas.
+func actualSchema(schema Schema) Schema {
+	for {
+		switch s := schema.(type) {
+		case *AliasSchema:
+			schema = s.RefSchema
+		case *RecursiveSchema:
+			return s.Actual
+		case *preparedRecordSchema:
+			return &s.RecordSchema
+		default:
+			return schema
+		}
+	}
+}
+
+func isNamed(schema Schema) bool {
+	switch schema.Type() {
+	case Record, Enum, Fixed:
+		return true
+	}
+
+	return false
+}
+
+// namesMatch checks whether the unqualified name of the writer's named schema is the one of the reader's named schema
+// or one of its aliases.
+func namesMatch(writer, reader Schema) bool {
+	name := unqualifiedName(writer.GetName())
+	if name == unqualifiedName(reader.GetName()) {
+		return true
+	}
+
+	var aliases []string
+	switch s := actualSchema(reader).(type) {
+	case *RecordSchema:
+		aliases = s.Aliases
+	case *EnumSchema:
+		aliases = s.Aliases
+	case *FixedSchema:
+		aliases = s.Aliases
+	}
+	for _, alias := range aliases {
+		if name == unqualifiedName(alias) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchFields returns the index of the reader's field matching each of the writer's fields or -1 if there is none.
+// Fields match by name or, if the writer has no field of the name of the reader's field, by one of its aliases.
+func matchFields(writer, reader *RecordSchema) []int {
+	writerFields := make(map[string]int, len(writer.Fields))
+	targets := make([]int, len(writer.Fields))
+	for i, field := range writer.Fields {
+		writerFields[field.Name] = i
+		targets[i] = -1
+	}
+
+	for target, field := range reader.Fields {
+		if i, ok := writerFields[field.Name]; ok {
+			targets[i] = target
+			continue
+		}
+		for _, alias := range field.Aliases {
+			if i, ok := writerFields[alias]; ok && targets[i] < 0 {
+				targets[i] = target
+				break
+			}
+		}
+	}
+	return targets
+}
+
+func unqualifiedName(name string) string {
+	return name[strings.LastIndex(name, ".")+1:]
+}
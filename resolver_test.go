@@ -0,0 +1,319 @@
+package avro
+
+import (
+	"bytes"
+	"testing"
+)
+
+// resolveDatum encodes a generic datum with the writer schema and decodes it as the reader schema
+func resolveDatum(t *testing.T, writerSchema, readerSchema Schema, datum interface{}) interface{} {
+	resolve, err := newResolveFunc(writerSchema, readerSchema)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	written := &bytes.Buffer{}
+	writer := NewGenericDatumWriter()
+	writer.SetSchema(writerSchema)
+	if err = writer.Write(datum, NewBinaryEncoder(written)); err != nil {
+		t.Fatal(err)
+	}
+
+	resolved := &bytes.Buffer{}
+	dec := NewBinaryDecoder(written.Bytes())
+	if err = resolve(dec, NewBinaryEncoder(resolved)); err != nil {
+		t.Fatal(err)
+	}
+	assert(t, dec.Tell(), int64(written.Len()))
+
+	reader := NewGenericDatumReader()
+	reader.SetSchema(readerSchema)
+	if readerSchema.Type() == Record {
+		record := NewGenericRecord(readerSchema)
+		if err = reader.Read(record, NewBinaryDecoder(resolved.Bytes())); err != nil {
+			t.Fatal(err)
+		}
+		return record
+	}
+
+	var value interface{}
+	if err = reader.Read(&value, NewBinaryDecoder(resolved.Bytes())); err != nil {
+		t.Fatal(err)
+	}
+	return value
+}
+
+func TestResolvePromotions(t *testing.T) {
+	assert(t, resolveDatum(t, &IntSchema{}, &LongSchema{}, int32(7)), int64(7))
+	assert(t, resolveDatum(t, &IntSchema{}, &FloatSchema{}, int32(7)), float32(7))
+	assert(t, resolveDatum(t, &IntSchema{}, &DoubleSchema{}, int32(-7)), float64(-7))
+	assert(t, resolveDatum(t, &LongSchema{}, &FloatSchema{}, int64(1)<<40), float32(1<<40))
+	assert(t, resolveDatum(t, &LongSchema{}, &DoubleSchema{}, int64(123)), float64(123))
+	assert(t, resolveDatum(t, &FloatSchema{}, &DoubleSchema{}, float32(1.5)), float64(1.5))
+
+	for _, pair := range [][2]Schema{
+		{&LongSchema{}, &IntSchema{}},
+		{&DoubleSchema{}, &FloatSchema{}},
+		{&StringSchema{}, &IntSchema{}},
+		{&BooleanSchema{}, &NullSchema{}},
+	} {
+		if _, err := newResolveFunc(pair[0], pair[1]); err == nil {
+			t.Fatalf("Expected an error resolving %s as %s", pair[0], pair[1])
+		}
+	}
+}
+
+func TestResolveRecord(t *testing.T) {
+	writerSchema := MustParseSchema(`{"type": "record", "name": "Rec", "namespace": "writer", "fields": [
+		{"name": "a", "type": "int"},
+		{"name": "removed", "type": {"type": "array", "items": {"type": "map", "values": "string"}}},
+		{"name": "b", "type": "string"},
+		{"name": "c", "type": {"type": "array", "items": "int"}}
+	]}`)
+	readerSchema := MustParseSchema(`{"type": "record", "name": "Rec", "namespace": "reader", "fields": [
+		{"name": "b", "type": "string"},
+		{"name": "added", "type": "long", "default": 42},
+		{"name": "a", "type": "double"},
+		{"name": "c", "type": {"type": "array", "items": "long"}},
+		{"name": "optional", "type": ["null", "string"], "default": null},
+		{"name": "nested", "type": {"type": "record", "name": "Nested", "fields": [
+			{"name": "x", "type": "string", "default": "none"},
+			{"name": "y", "type": {"type": "map", "values": "int"}, "default": {"one": 1}}
+		]}, "default": {"x": "given"}}
+	]}`)
+
+	datum := NewGenericRecord(writerSchema)
+	datum.Set("a", int32(3))
+	datum.Set("removed", []interface{}{map[string]interface{}{"k": "v"}})
+	datum.Set("b", "bee")
+	datum.Set("c", []interface{}{int32(1), int32(2)})
+
+	record := resolveDatum(t, writerSchema, readerSchema, datum).(*GenericRecord)
+	assert(t, record.Get("a"), float64(3))
+	assert(t, record.Get("b"), "bee")
+	assert(t, record.Get("c"), []interface{}{int64(1), int64(2)})
+	assert(t, record.Get("added"), int64(42))
+	assert(t, record.Get("optional"), nil)
+	assert(t, record.Get("removed"), nil)
+	nested := record.Get("nested").(*GenericRecord)
+	assert(t, nested.Get("x"), "given")
+	assert(t, nested.Get("y"), map[string]interface{}{"one": int32(1)})
+
+	noDefault := MustParseSchema(`{"type": "record", "name": "Rec", "fields": [
+		{"name": "a", "type": "int"},
+		{"name": "missing", "type": "string"}
+	]}`)
+	if _, err := newResolveFunc(writerSchema, noDefault); err == nil {
+		t.Fatal("Expected an error for a missing field without default")
+	}
+	if _, err := newResolveFunc(writerSchema, MustParseSchema(`{"type": "record", "name": "Other", "fields": []}`)); err == nil {
+		t.Fatal("Expected an error for records with different names")
+	}
+}
+
+func TestResolveUnions(t *testing.T) {
+	union := MustParseSchema(`["null", "int", "string"]`)
+
+	// writer's union to a reader's non-union schema, failing only for unresolvable branches
+	resolve, err := newResolveFunc(union, &LongSchema{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert(t, resolveDatum(t, union, &LongSchema{}, int32(5)), int64(5))
+	buf := &bytes.Buffer{}
+	writer := NewGenericDatumWriter()
+	writer.SetSchema(union)
+	assert(t, writer.Write("text", NewBinaryEncoder(buf)), nil)
+	if err = resolve(NewBinaryDecoder(buf.Bytes()), NewBinaryEncoder(&bytes.Buffer{})); err == nil {
+		t.Fatal("Expected an error resolving a string branch as long")
+	}
+
+	// non-union to a reader's union, promoting if there is no exact match
+	assert(t, resolveDatum(t, &StringSchema{}, union, "text"), "text")
+	assert(t, resolveDatum(t, &LongSchema{}, MustParseSchema(`["null", "double"]`), int64(9)), float64(9))
+
+	// union to union
+	assert(t, resolveDatum(t, union, MustParseSchema(`["string", "long", "null"]`), int32(8)), int64(8))
+	assert(t, resolveDatum(t, union, MustParseSchema(`["string", "long", "null"]`), "text"), "text")
+}
+
+func TestResolveRecursive(t *testing.T) {
+	writerSchema := MustParseSchema(`{"type": "record", "name": "Node", "fields": [
+		{"name": "value", "type": "int"},
+		{"name": "next", "type": ["null", "Node"]}
+	]}`)
+	readerSchema := MustParseSchema(`{"type": "record", "name": "Node", "fields": [
+		{"name": "next", "type": ["null", "Node"]},
+		{"name": "value", "type": "long"},
+		{"name": "label", "type": "string", "default": "node"}
+	]}`)
+
+	tail := NewGenericRecord(writerSchema)
+	tail.Set("value", int32(2))
+	head := NewGenericRecord(writerSchema)
+	head.Set("value", int32(1))
+	head.Set("next", tail)
+
+	record := resolveDatum(t, writerSchema, readerSchema, head).(*GenericRecord)
+	assert(t, record.Get("value"), int64(1))
+	assert(t, record.Get("label"), "node")
+	next := record.Get("next").(*GenericRecord)
+	assert(t, next.Get("value"), int64(2))
+	assert(t, next.Get("label"), "node")
+	assert(t, next.Get("next"), nil)
+}
+
+func TestResolveStringsAndBytes(t *testing.T) {
+	assert(t, resolveDatum(t, &StringSchema{}, &BytesSchema{}, "text"), []byte("text"))
+	assert(t, resolveDatum(t, &BytesSchema{}, &StringSchema{}, []byte("text")), "text")
+	assert(t, resolveDatum(t, &BytesSchema{}, MustParseSchema(`["null", "int", "string"]`), []byte("text")), "text")
+}
+
+func TestResolveEnum(t *testing.T) {
+	writerSchema := MustParseSchema(`{"type": "enum", "name": "Color", "symbols": ["RED", "GREEN", "BLUE"]}`)
+	readerSchema := MustParseSchema(`{"type": "enum", "name": "Color", "symbols": ["BLUE", "YELLOW", "RED"]}`)
+	resolve, err := newResolveFunc(writerSchema, readerSchema)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, c := range []struct {
+		written  int32
+		resolved int32
+	}{{0, 2}, {2, 0}} {
+		buf := &bytes.Buffer{}
+		NewBinaryEncoder(buf).WriteInt(c.written)
+		resolved := &bytes.Buffer{}
+		assert(t, resolve(NewBinaryDecoder(buf.Bytes()), NewBinaryEncoder(resolved)), nil)
+		index, _ := NewBinaryDecoder(resolved.Bytes()).ReadEnum()
+		assert(t, index, c.resolved)
+	}
+
+	buf := &bytes.Buffer{}
+	NewBinaryEncoder(buf).WriteInt(1)
+	if err = resolve(NewBinaryDecoder(buf.Bytes()), NewBinaryEncoder(&bytes.Buffer{})); err == nil {
+		t.Fatal("Expected an error resolving a symbol unknown to the reader")
+	}
+}
+
+type resolvedRecord struct {
+	Name  string  `avro:"name"`
+	Score float64 `avro:"score"`
+	Tags  []byte  `avro:"tags"`
+	Added int64   `avro:"added"`
+}
+
+func TestDatumReaderWriterSchema(t *testing.T) {
+	writerSchema := MustParseSchema(`{"type": "record", "name": "Player", "fields": [
+		{"name": "score", "type": "int"},
+		{"name": "removed", "type": "string"},
+		{"name": "tags", "type": "string"},
+		{"name": "name", "type": "string"}
+	]}`)
+	readerSchema := MustParseSchema(`{"type": "record", "name": "Player", "fields": [
+		{"name": "name", "type": "string"},
+		{"name": "score", "type": "double"},
+		{"name": "tags", "type": "bytes"},
+		{"name": "added", "type": "long", "default": 7}
+	]}`)
+
+	datum := NewGenericRecord(writerSchema)
+	datum.Set("score", int32(10))
+	datum.Set("removed", "gone")
+	datum.Set("tags", "a,b")
+	datum.Set("name", "player")
+	buf := &bytes.Buffer{}
+	writer := NewGenericDatumWriter()
+	writer.SetSchema(writerSchema)
+	assert(t, writer.Write(datum, NewBinaryEncoder(buf)), nil)
+
+	specific := NewSpecificDatumReader()
+	specific.SetSchema(readerSchema)
+	assert(t, specific.SetWriterSchema(writerSchema), nil)
+	player := &resolvedRecord{}
+	dec := NewBinaryDecoder(buf.Bytes())
+	assert(t, specific.Read(player, dec), nil)
+	assert(t, player, &resolvedRecord{Name: "player", Score: 10, Tags: []byte("a,b"), Added: 7})
+	assert(t, dec.Tell(), int64(buf.Len()))
+
+	generic := NewGenericDatumReader()
+	assert(t, generic.SetWriterSchema(writerSchema), nil)
+	generic.SetSchema(readerSchema)
+	record := NewGenericRecord(readerSchema)
+	assert(t, generic.Read(record, NewBinaryDecoder(buf.Bytes())), nil)
+	assert(t, record.Get("name"), "player")
+	assert(t, record.Get("score"), float64(10))
+	assert(t, record.Get("added"), int64(7))
+
+	if err := specific.SetWriterSchema(MustParseSchema(`{"type": "record", "name": "Player", "fields": []}`)); err == nil {
+		t.Fatal("Expected an error resolving a writer's schema lacking a field without default")
+	}
+	if err := specific.Read(player, NewBinaryDecoder(buf.Bytes())); err == nil {
+		t.Fatal("Expected the resolution error on read")
+	}
+	assert(t, specific.SetWriterSchema(nil), nil)
+}
+
+func TestResolveAliases(t *testing.T) {
+	writerSchema := MustParseSchema(`{"type": "record", "name": "OldUser", "namespace": "old", "fields": [
+		{"name": "uid", "type": "int"},
+		{"name": "nick", "type": "string"},
+		{"name": "name", "type": "string"},
+		{"name": "kind", "type": {"type": "enum", "name": "OldKind", "symbols": ["A", "B"]}}
+	]}`)
+	readerSchema := MustParseSchema(`{"type": "record", "name": "User", "aliases": ["old.OldUser"], "fields": [
+		{"name": "id", "type": "long", "aliases": ["uid"]},
+		{"name": "nickname", "type": "string", "aliases": ["nick"]},
+		{"name": "display", "type": "string", "aliases": ["name"], "default": ""},
+		{"name": "name", "type": "string"},
+		{"name": "kind", "type": {"type": "enum", "name": "Kind", "aliases": ["OldKind"], "symbols": ["B", "A"]}}
+	]}`)
+	assert(t, readerSchema.(*RecordSchema).Fields[0].Aliases, []string{"uid"})
+	assert(t, MustParseSchema(readerSchema.String()).String(), readerSchema.String())
+
+	datum := NewGenericRecord(writerSchema)
+	datum.Set("uid", int32(1))
+	datum.Set("nick", "nick")
+	datum.Set("name", "name")
+	datum.Set("kind", "A")
+
+	record := resolveDatum(t, writerSchema, readerSchema, datum).(*GenericRecord)
+	assert(t, record.Get("id"), int64(1))
+	assert(t, record.Get("nickname"), "nick")
+	assert(t, record.Get("name"), "name")
+	assert(t, record.Get("display"), "")
+	assert(t, record.Get("kind"), "A")
+
+	assert(t, CheckReaderWriterCompatibility(readerSchema, writerSchema), []Incompatibility(nil))
+
+	// prepared schemas keep the aliases of their fields
+	writerSchema = MustParseSchema(`{"type": "record", "name": "R", "fields": [{"name": "old", "type": "int"}]}`)
+	readerSchema = MustParseSchema(`{"type": "record", "name": "R", "fields": [
+		{"name": "renamed", "type": "int", "aliases": ["old"]}
+	]}`)
+	buf := &bytes.Buffer{}
+	writer := NewSpecificDatumWriter()
+	writer.SetSchema(writerSchema)
+	assert(t, writer.Write(&struct {
+		Old int32 `avro:"old"`
+	}{42}, NewBinaryEncoder(buf)), nil)
+	reader := NewSpecificDatumReader()
+	reader.SetSchema(Prepare(readerSchema))
+	assert(t, reader.SetWriterSchema(writerSchema), nil)
+	renamed := &struct {
+		Renamed int32 `avro:"renamed"`
+	}{}
+	assert(t, reader.Read(renamed, NewBinaryDecoder(buf.Bytes())), nil)
+	assert(t, renamed.Renamed, int32(42))
+}
+
+func TestResolveEnumDefault(t *testing.T) {
+	writerSchema := MustParseSchema(`{"type": "enum", "name": "State", "symbols": ["OPEN", "REOPENED", "CLOSED"]}`)
+	readerSchema := MustParseSchema(`{"type": "enum", "name": "State", "symbols": ["UNKNOWN", "OPEN", "CLOSED"], "default": "UNKNOWN"}`)
+
+	reopened := resolveDatum(t, writerSchema, readerSchema, "REOPENED").(GenericEnum)
+	assert(t, reopened.Get(), "UNKNOWN")
+	closed := resolveDatum(t, writerSchema, readerSchema, "CLOSED").(GenericEnum)
+	assert(t, closed.Get(), "CLOSED")
+	assert(t, CheckReaderWriterCompatibility(readerSchema, writerSchema), []Incompatibility(nil))
+}
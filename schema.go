@@ -61,6 +61,7 @@ const (
 
 const (
 	typeRecord  = "record"
+	typeError   = "error"
 	typeUnion   = "union"
 	typeEnum    = "enum"
 	typeArray   = "array"
@@ -84,6 +85,7 @@ const (
 	schemaItemsField     = "items"
 	schemaNameField      = "name"
 	schemaNamespaceField = "namespace"
+	schemaOrderField     = "order"
 	schemaSizeField      = "size"
 	schemaSymbolsField   = "symbols"
 	schemaTypeField      = "type"
@@ -109,10 +111,21 @@ type Schema interface {
 }
 
 // StringSchema implements Schema and represents Avro string type.
-type StringSchema struct{}
+type StringSchema struct {
+	// LogicalType is either nil or a *UUIDType.
+	LogicalType LogicalType
+}
 
 // Returns a JSON representation of StringSchema.
-func (*StringSchema) String() string {
+func (s *StringSchema) String() string {
+	if s.LogicalType != nil {
+		bytes, err := json.Marshal(s)
+		if err != nil {
+			panic(err)
+		}
+		return string(bytes)
+	}
+
 	return `{"type": "string"}`
 }
 
@@ -132,21 +145,41 @@ func (*StringSchema) Prop(key string) (interface{}, bool) {
 }
 
 // Validate checks whether the given value is writeable to this schema.
-func (*StringSchema) Validate(v reflect.Value) bool {
+func (s *StringSchema) Validate(v reflect.Value) bool {
+	if isUUID(s) {
+		_, ok := uuidString(v)
+		return ok
+	}
+
 	_, ok := dereference(v).Interface().(string)
 	return ok
 }
 
 // MarshalJSON serializes the given schema as JSON. Never returns an error.
-func (*StringSchema) MarshalJSON() ([]byte, error) {
+func (s *StringSchema) MarshalJSON() ([]byte, error) {
+	if s.LogicalType != nil {
+		return json.Marshal(logicalTypeJSON(typeString, s.LogicalType))
+	}
+
 	return []byte(`"string"`), nil
 }
 
 // BytesSchema implements Schema and represents Avro bytes type.
-type BytesSchema struct{}
+type BytesSchema struct {
+	// LogicalType is either nil or a *DecimalType.
+	LogicalType LogicalType
+}
 
 // String returns a JSON representation of BytesSchema.
-func (*BytesSchema) String() string {
+func (s *BytesSchema) String() string {
+	if s.LogicalType != nil {
+		bytes, err := json.Marshal(s)
+		if err != nil {
+			panic(err)
+		}
+		return string(bytes)
+	}
+
 	return `{"type": "bytes"}`
 }
 
@@ -166,22 +199,40 @@ func (*BytesSchema) Prop(key string) (interface{}, bool) {
 }
 
 // Validate checks whether the given value is writeable to this schema.
-func (*BytesSchema) Validate(v reflect.Value) bool {
+func (s *BytesSchema) Validate(v reflect.Value) bool {
+	if isDecimal(s, v) {
+		return true
+	}
 	v = dereference(v)
 
 	return v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8
 }
 
 // MarshalJSON serializes the given schema as JSON. Never returns an error.
-func (*BytesSchema) MarshalJSON() ([]byte, error) {
+func (s *BytesSchema) MarshalJSON() ([]byte, error) {
+	if s.LogicalType != nil {
+		return json.Marshal(logicalTypeJSON(typeBytes, s.LogicalType))
+	}
+
 	return []byte(`"bytes"`), nil
 }
 
 // IntSchema implements Schema and represents Avro int type.
-type IntSchema struct{}
+type IntSchema struct {
+	// LogicalType is either nil or a TemporalType.
+	LogicalType LogicalType
+}
 
 // String returns a JSON representation of IntSchema.
-func (*IntSchema) String() string {
+func (s *IntSchema) String() string {
+	if s.LogicalType != nil {
+		bytes, err := json.Marshal(s)
+		if err != nil {
+			panic(err)
+		}
+		return string(bytes)
+	}
+
 	return `{"type": "int"}`
 }
 
@@ -201,20 +252,39 @@ func (*IntSchema) Prop(key string) (interface{}, bool) {
 }
 
 // Validate checks whether the given value is writeable to this schema.
-func (*IntSchema) Validate(v reflect.Value) bool {
+func (s *IntSchema) Validate(v reflect.Value) bool {
+	if _, ok := temporalValue(s, v); ok {
+		return true
+	}
+
 	return reflect.TypeOf(dereference(v).Interface()).Kind() == reflect.Int32
 }
 
 // MarshalJSON serializes the given schema as JSON. Never returns an error.
-func (*IntSchema) MarshalJSON() ([]byte, error) {
+func (s *IntSchema) MarshalJSON() ([]byte, error) {
+	if s.LogicalType != nil {
+		return json.Marshal(logicalTypeJSON(typeInt, s.LogicalType))
+	}
+
 	return []byte(`"int"`), nil
 }
 
 // LongSchema implements Schema and represents Avro long type.
-type LongSchema struct{}
+type LongSchema struct {
+	// LogicalType is either nil or a TemporalType.
+	LogicalType LogicalType
+}
 
 // Returns a JSON representation of LongSchema.
-func (*LongSchema) String() string {
+func (s *LongSchema) String() string {
+	if s.LogicalType != nil {
+		bytes, err := json.Marshal(s)
+		if err != nil {
+			panic(err)
+		}
+		return string(bytes)
+	}
+
 	return `{"type": "long"}`
 }
 
@@ -234,12 +304,21 @@ func (*LongSchema) Prop(key string) (interface{}, bool) {
 }
 
 // Validate checks whether the given value is writeable to this schema.
-func (*LongSchema) Validate(v reflect.Value) bool {
-	return reflect.TypeOf(dereference(v).Interface()).Kind() == reflect.Int64
+func (s *LongSchema) Validate(v reflect.Value) bool {
+	if _, ok := temporalValue(s, v); ok {
+		return true
+	}
+
+	t := reflect.TypeOf(dereference(v).Interface())
+	return t.Kind() == reflect.Int64 && t != durationType
 }
 
 // MarshalJSON serializes the given schema as JSON. Never returns an error.
-func (*LongSchema) MarshalJSON() ([]byte, error) {
+func (s *LongSchema) MarshalJSON() ([]byte, error) {
+	if s.LogicalType != nil {
+		return json.Marshal(logicalTypeJSON(typeLong, s.LogicalType))
+	}
+
 	return []byte(`"long"`), nil
 }
 
@@ -408,6 +487,9 @@ type RecordSchema struct {
 	Aliases    []string `json:"aliases,omitempty"`
 	Properties map[string]interface{}
 	Fields     []*SchemaField `json:"fields"`
+
+	// IsError tells whether this record is an error declared by a protocol.
+	IsError bool
 }
 
 // String returns a JSON representation of RecordSchema.
@@ -422,6 +504,11 @@ func (s *RecordSchema) String() string {
 
 // MarshalJSON serializes the given schema as JSON.
 func (s *RecordSchema) MarshalJSON() ([]byte, error) {
+	typeName := typeRecord
+	if s.IsError {
+		typeName = typeError
+	}
+
 	return json.Marshal(struct {
 		Type      string         `json:"type,omitempty"`
 		Namespace string         `json:"namespace,omitempty"`
@@ -430,7 +517,7 @@ func (s *RecordSchema) MarshalJSON() ([]byte, error) {
 		Aliases   []string       `json:"aliases,omitempty"`
 		Fields    []*SchemaField `json:"fields"`
 	}{
-		Type:      "record",
+		Type:      typeName,
 		Namespace: s.Namespace,
 		Name:      s.Name,
 		Doc:       s.Doc,
@@ -539,13 +626,34 @@ func (s *RecursiveSchema) MarshalJSON() ([]byte, error) {
 	return []byte(fmt.Sprintf(`"%s"`, s.Actual.GetName())), nil
 }
 
+// FieldOrder tells how a field affects the sort order of records.
+type FieldOrder string
+
+const (
+	// AscendingOrder sorts records by the field in ascending order, the default.
+	AscendingOrder FieldOrder = "ascending"
+
+	// DescendingOrder sorts records by the field in descending order.
+	DescendingOrder FieldOrder = "descending"
+
+	// IgnoreOrder ignores the field when sorting records.
+	IgnoreOrder FieldOrder = "ignore"
+)
+
 // SchemaField represents a schema field for Avro record.
 type SchemaField struct {
 	Name       string      `json:"name,omitempty"`
+	Aliases    []string    `json:"aliases,omitempty"`
 	Doc        string      `json:"doc,omitempty"`
 	Default    interface{} `json:"default"`
 	Type       Schema      `json:"type,omitempty"`
 	Properties map[string]interface{}
+
+	// Order is the sort order of this field, empty meaning AscendingOrder.
+	Order FieldOrder `json:"order,omitempty"`
+
+	// HasDefault tells whether this field has a default value, which may be a nil Default for a null default.
+	HasDefault bool `json:"-"`
 }
 
 // Gets a custom non-reserved property from this schemafield and a bool representing if it exists.
@@ -563,27 +671,35 @@ func (s *SchemaField) MarshalJSON() ([]byte, error) {
 	if s.Type.Type() == Null || (s.Type.Type() == Union && s.Type.(*UnionSchema).Types[0].Type() == Null) {
 		return json.Marshal(struct {
 			Name    string      `json:"name,omitempty"`
+			Aliases []string    `json:"aliases,omitempty"`
 			Doc     string      `json:"doc,omitempty"`
 			Default interface{} `json:"default"`
 			Type    Schema      `json:"type,omitempty"`
+			Order   FieldOrder  `json:"order,omitempty"`
 		}{
 			Name:    s.Name,
+			Aliases: s.Aliases,
 			Doc:     s.Doc,
 			Default: s.Default,
 			Type:    s.Type,
+			Order:   s.Order,
 		})
 	}
 
 	return json.Marshal(struct {
 		Name    string      `json:"name,omitempty"`
+		Aliases []string    `json:"aliases,omitempty"`
 		Doc     string      `json:"doc,omitempty"`
 		Default interface{} `json:"default,omitempty"`
 		Type    Schema      `json:"type,omitempty"`
+		Order   FieldOrder  `json:"order,omitempty"`
 	}{
 		Name:    s.Name,
+		Aliases: s.Aliases,
 		Doc:     s.Doc,
 		Default: s.Default,
 		Type:    s.Type,
+		Order:   s.Order,
 	})
 }
 
@@ -600,6 +716,9 @@ type EnumSchema struct {
 	Doc        string
 	Symbols    []string
 	Properties map[string]interface{}
+
+	// Default is the symbol used when reading a symbol unknown to this EnumSchema, empty if there is none.
+	Default string
 }
 
 // String returns a JSON representation of EnumSchema.
@@ -645,14 +764,18 @@ func (s *EnumSchema) MarshalJSON() ([]byte, error) {
 		Type      string   `json:"type,omitempty"`
 		Namespace string   `json:"namespace,omitempty"`
 		Name      string   `json:"name,omitempty"`
+		Aliases   []string `json:"aliases,omitempty"`
 		Doc       string   `json:"doc,omitempty"`
 		Symbols   []string `json:"symbols,omitempty"`
+		Default   string   `json:"default,omitempty"`
 	}{
 		Type:      "enum",
 		Namespace: s.Namespace,
 		Name:      s.Name,
+		Aliases:   s.Aliases,
 		Doc:       s.Doc,
 		Symbols:   s.Symbols,
+		Default:   s.Default,
 	})
 }
 
@@ -830,8 +953,12 @@ func (s *UnionSchema) MarshalJSON() ([]byte, error) {
 type FixedSchema struct {
 	Namespace  string
 	Name       string
+	Aliases    []string
 	Size       int
 	Properties map[string]interface{}
+
+	// LogicalType is either nil, a *DecimalType or a *DurationType.
+	LogicalType LogicalType
 }
 
 // String returns a JSON representation of FixedSchema.
@@ -866,6 +993,9 @@ func (s *FixedSchema) Prop(key string) (interface{}, bool) {
 
 // Validate checks whether the given value is writeable to this schema.
 func (s *FixedSchema) Validate(v reflect.Value) bool {
+	if isDecimal(s, v) || isDuration(s, v) {
+		return true
+	}
 	v = dereference(v)
 
 	return (v.Kind() == reflect.Array || v.Kind() == reflect.Slice) && v.Type().Elem().Kind() == reflect.Uint8 && v.Len() == s.Size
@@ -873,14 +1003,31 @@ func (s *FixedSchema) Validate(v reflect.Value) bool {
 
 // MarshalJSON serializes the given schema as JSON.
 func (s *FixedSchema) MarshalJSON() ([]byte, error) {
+	if s.LogicalType != nil {
+		v := logicalTypeJSON(typeFixed, s.LogicalType)
+		v[schemaSizeField] = s.Size
+		if s.Namespace != "" {
+			v[schemaNamespaceField] = s.Namespace
+		}
+		v[schemaNameField] = s.Name
+		if len(s.Aliases) > 0 {
+			v[schemaAliasesField] = s.Aliases
+		}
+		return json.Marshal(v)
+	}
+
 	return json.Marshal(struct {
-		Type string `json:"type,omitempty"`
-		Size int    `json:"size,omitempty"`
-		Name string `json:"name,omitempty"`
+		Type      string   `json:"type,omitempty"`
+		Size      int      `json:"size,omitempty"`
+		Namespace string   `json:"namespace,omitempty"`
+		Name      string   `json:"name,omitempty"`
+		Aliases   []string `json:"aliases,omitempty"`
 	}{
-		Type: "fixed",
-		Size: s.Size,
-		Name: s.Name,
+		Type:      "fixed",
+		Size:      s.Size,
+		Namespace: s.Namespace,
+		Name:      s.Name,
+		Aliases:   s.Aliases,
 	})
 }
 
@@ -1009,7 +1156,7 @@ func schemaByType(i interface{}, registry map[string]Schema, namespace string) (
 
 			// duplicate schema should use aliase type name, mainly for same enum fields
 			aliasSchema := &AliasSchema{
-				AliasType: schema.GetName(),
+				AliasType: referenceName(fullName, namespace),
 				RefSchema: schema,
 			}
 			return aliasSchema, nil
@@ -1024,17 +1171,25 @@ func schemaByType(i interface{}, registry map[string]Schema, namespace string) (
 		case typeBoolean:
 			return new(BooleanSchema), nil
 		case typeInt:
-			return new(IntSchema), nil
+			schema := new(IntSchema)
+			schema.LogicalType = parseLogicalType(v, schema)
+			return schema, nil
 		case typeLong:
-			return new(LongSchema), nil
+			schema := new(LongSchema)
+			schema.LogicalType = parseLogicalType(v, schema)
+			return schema, nil
 		case typeFloat:
 			return new(FloatSchema), nil
 		case typeDouble:
 			return new(DoubleSchema), nil
 		case typeBytes:
-			return new(BytesSchema), nil
+			schema := new(BytesSchema)
+			schema.LogicalType = parseLogicalType(v, schema)
+			return schema, nil
 		case typeString:
-			return new(StringSchema), nil
+			schema := new(StringSchema)
+			schema.LogicalType = parseLogicalType(v, schema)
+			return schema, nil
 		case typeArray:
 			items, err := schemaByType(v[schemaItemsField], registry, namespace)
 			if err != nil {
@@ -1051,7 +1206,7 @@ func schemaByType(i interface{}, registry map[string]Schema, namespace string) (
 			return parseEnumSchema(v, registry, namespace)
 		case typeFixed:
 			return parseFixedSchema(v, registry, namespace)
-		case typeRecord:
+		case typeRecord, typeError:
 			return parseRecordSchema(v, registry, namespace)
 		default:
 			// Type references can also be done as {"type": "otherType"}.
@@ -1074,7 +1229,16 @@ func parseEnumSchema(v map[string]interface{}, registry map[string]Schema, names
 
 	schema := &EnumSchema{Name: v[schemaNameField].(string), Symbols: symbols}
 	setOptionalField(&schema.Namespace, v, schemaNamespaceField)
+	setOptionalField(&namespace, v, schemaNamespaceField)
 	setOptionalField(&schema.Doc, v, schemaDocField)
+	setOptionalAliases(&schema.Aliases, v)
+	if def, exists := v[schemaDefaultField]; exists {
+		symbol, ok := def.(string)
+		if !ok || !containsSymbol(symbols, symbol) {
+			return nil, fmt.Errorf("Invalid default symbol of enum %s: %v", schema.Name, def)
+		}
+		schema.Default = symbol
+	}
 	schema.Properties = getProperties(v)
 
 	return addSchema(getFullName(v[schemaNameField].(string), namespace), schema, registry), nil
@@ -1088,6 +1252,9 @@ func parseFixedSchema(v map[string]interface{}, registry map[string]Schema, name
 
 	schema := &FixedSchema{Name: v[schemaNameField].(string), Size: int(size), Properties: getProperties(v)}
 	setOptionalField(&schema.Namespace, v, schemaNamespaceField)
+	setOptionalField(&namespace, v, schemaNamespaceField)
+	setOptionalAliases(&schema.Aliases, v)
+	schema.LogicalType = parseLogicalType(v, schema)
 	return addSchema(getFullName(v[schemaNameField].(string), namespace), schema, registry), nil
 }
 
@@ -1104,11 +1271,13 @@ func parseUnionSchema(v []interface{}, registry map[string]Schema, namespace str
 }
 
 func parseRecordSchema(v map[string]interface{}, registry map[string]Schema, namespace string) (Schema, error) {
-	schema := &RecordSchema{Name: v[schemaNameField].(string)}
+	schema := &RecordSchema{Name: v[schemaNameField].(string), IsError: v[schemaTypeField] == typeError}
 	setOptionalField(&schema.Namespace, v, schemaNamespaceField)
-	setOptionalField(&namespace, v, schemaNamespaceField)
 	setOptionalField(&schema.Doc, v, schemaDocField)
-	addSchema(getFullName(v[schemaNameField].(string), namespace), newRecursiveSchema(schema), registry)
+	setOptionalAliases(&schema.Aliases, v)
+	// the schemas a record encloses are in the namespace of its full name
+	fullName, namespace := canonicalName(schema.Name, schema.Namespace, namespace)
+	addSchema(fullName, newRecursiveSchema(schema), registry)
 	fields := make([]*SchemaField, len(v[schemaFieldsField].([]interface{})))
 	for i := range fields {
 		field, err := parseSchemaField(v[schemaFieldsField].([]interface{})[i], registry, namespace)
@@ -1132,22 +1301,39 @@ func parseSchemaField(i interface{}, registry map[string]Schema, namespace strin
 		}
 		schemaField := &SchemaField{Name: name, Properties: getProperties(v)}
 		setOptionalField(&schemaField.Doc, v, schemaDocField)
+		setOptionalAliases(&schemaField.Aliases, v)
+		if order, exists := v[schemaOrderField]; exists {
+			switch order {
+			case string(AscendingOrder), string(DescendingOrder), string(IgnoreOrder):
+				schemaField.Order = FieldOrder(order.(string))
+			default:
+				return nil, fmt.Errorf("Invalid order of field %s: %v", name, order)
+			}
+		}
 		fieldType, err := schemaByType(v[schemaTypeField], registry, namespace)
 		if err != nil {
 			return nil, err
 		}
 		schemaField.Type = fieldType
 		if def, exists := v[schemaDefaultField]; exists {
+			schemaField.HasDefault = true
 			switch def.(type) {
 			case float64:
 				// JSON treats all numbers as float64 by default
+				// defaults that are no valid integers are kept as they are, so ValidateSchema reports them
 				switch schemaField.Type.Type() {
 				case Int:
-					var converted = int32(def.(float64))
-					schemaField.Default = converted
+					if converted, ok := jsonInt(def); ok {
+						schemaField.Default = int32(converted)
+					} else {
+						schemaField.Default = def
+					}
 				case Long:
-					var converted = int64(def.(float64))
-					schemaField.Default = converted
+					if number := def.(float64); number == math.Trunc(number) {
+						schemaField.Default = int64(number)
+					} else {
+						schemaField.Default = def
+					}
 				case Float:
 					var converted = float32(def.(float64))
 					schemaField.Default = converted
@@ -1171,6 +1357,27 @@ func setOptionalField(where *string, v map[string]interface{}, fieldName string)
 	}
 }
 
+func containsSymbol(symbols []string, symbol string) bool {
+	for _, s := range symbols {
+		if s == symbol {
+			return true
+		}
+	}
+
+	return false
+}
+
+func setOptionalAliases(where *[]string, v map[string]interface{}) {
+	if aliases, ok := v[schemaAliasesField].([]interface{}); ok {
+		*where = make([]string, 0, len(aliases))
+		for _, alias := range aliases {
+			if name, ok := alias.(string); ok {
+				*where = append(*where, name)
+			}
+		}
+	}
+}
+
 func addSchema(name string, schema Schema, schemas map[string]Schema) Schema {
 	if schemas != nil {
 		if sch, ok := schemas[name]; ok {
@@ -1191,6 +1398,16 @@ func getFullName(name string, namespace string) string {
 	return name
 }
 
+// gets the name referring to the type with the given full name from within the given namespace, which is the full
+// name unless the type is in that namespace
+func referenceName(fullName string, namespace string) string {
+	if i := strings.LastIndex(fullName, "."); i >= 0 && fullName[:i] == namespace {
+		return fullName[i+1:]
+	}
+
+	return fullName
+}
+
 // gets custom string properties from a given schema
 func getProperties(v map[string]interface{}) map[string]interface{} {
 	props := make(map[string]interface{})
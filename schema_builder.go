@@ -0,0 +1,671 @@
+package avro
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// SchemaBuilder builds schemas in Go, producing the same schema types as ParseSchema. Schemas are built in the order
+// of their JSON representation: Record, Enum, Fixed, Array, Map and Union start a schema, which is completed by its
+// fields, symbols, size, items, values or branches, and any completed schema becomes part of the innermost schema or
+// field it is built in. For example:
+//
+//	schema, err := NewSchemaBuilder().
+//		Record("User").Namespace("example.avro").
+//		Field("id").Long().
+//		Field("email").Optional().String().
+//		Field("tags").Array().String().
+//		Field("role").Enum("Role").Default("USER").Symbols("USER", "ADMIN").
+//		Field("manager").Optional().Ref("User").
+//		EndRecord().
+//		Build()
+//
+// Attributes like Namespace, Doc and Default are set on the innermost started schema or field, so attributes of a
+// field are given before its type. The first mistake in using a SchemaBuilder is returned by Build, which also
+// validates the built schema with ValidateSchema.
+type SchemaBuilder struct {
+	stack  []*builderFrame
+	schema Schema
+	err    error
+
+	// named schemas by their full names, like the registry of ParseSchemaWithRegistry
+	registry map[string]Schema
+
+	// modifiers of the next schema
+	optional    bool
+	logicalType LogicalType
+}
+
+// builderFrame is a schema or field started but not completed yet.
+type builderFrame struct {
+	// schema is the *RecordSchema, *EnumSchema, *FixedSchema, *ArraySchema, *MapSchema or *UnionSchema being built,
+	// or nil for a field or the top level.
+	schema Schema
+	field  *SchemaField
+
+	// optional tells whether Optional was called for the schema.
+	optional bool
+
+	// defined tells whether the name of a record is registered, after which the schemas it encloses are in namespace.
+	defined   bool
+	namespace string
+}
+
+// NewSchemaBuilder creates a new SchemaBuilder.
+func NewSchemaBuilder() *SchemaBuilder {
+	return &SchemaBuilder{registry: make(map[string]Schema)}
+}
+
+// Build returns the built schema, or the first mistake in using this SchemaBuilder. Returns SchemaViolations if the
+// built schema does not conform to the specification.
+func (b *SchemaBuilder) Build() (Schema, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	if len(b.stack) > 0 || b.schema == nil {
+		return nil, b.unexpected("the end of the schema").err
+	}
+	if b.pending("the end of the schema") {
+		return nil, b.err
+	}
+
+	if violations := ValidateSchema(b.schema); len(violations) > 0 {
+		return nil, violations
+	}
+	return b.schema, nil
+}
+
+// MustBuild is like Build, but panics if the schema cannot be built.
+func (b *SchemaBuilder) MustBuild() Schema {
+	s, err := b.Build()
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// Null adds a null schema.
+func (b *SchemaBuilder) Null() *SchemaBuilder {
+	return b.add(new(NullSchema))
+}
+
+// Boolean adds a boolean schema.
+func (b *SchemaBuilder) Boolean() *SchemaBuilder {
+	return b.add(new(BooleanSchema))
+}
+
+// Int adds an int schema.
+func (b *SchemaBuilder) Int() *SchemaBuilder {
+	schema := new(IntSchema)
+	schema.LogicalType = b.logical(typeInt, schema)
+	return b.add(schema)
+}
+
+// Long adds a long schema.
+func (b *SchemaBuilder) Long() *SchemaBuilder {
+	schema := new(LongSchema)
+	schema.LogicalType = b.logical(typeLong, schema)
+	return b.add(schema)
+}
+
+// Float adds a float schema.
+func (b *SchemaBuilder) Float() *SchemaBuilder {
+	return b.add(new(FloatSchema))
+}
+
+// Double adds a double schema.
+func (b *SchemaBuilder) Double() *SchemaBuilder {
+	return b.add(new(DoubleSchema))
+}
+
+// Bytes adds a bytes schema.
+func (b *SchemaBuilder) Bytes() *SchemaBuilder {
+	schema := new(BytesSchema)
+	schema.LogicalType = b.logical(typeBytes, schema)
+	return b.add(schema)
+}
+
+// String adds a string schema.
+func (b *SchemaBuilder) String() *SchemaBuilder {
+	schema := new(StringSchema)
+	schema.LogicalType = b.logical(typeString, schema)
+	return b.add(schema)
+}
+
+// Ref adds a reference to a record, enum or fixed defined before by this SchemaBuilder. Names without a dot are
+// looked up in the namespace of the innermost record, like in JSON schemas.
+func (b *SchemaBuilder) Ref(name string) *SchemaBuilder {
+	if b.err != nil {
+		return b
+	}
+
+	fullName := name
+	if !strings.ContainsRune(name, '.') {
+		fullName = getFullName(name, b.namespace())
+	}
+	schema, ok := b.registry[fullName]
+	if !ok {
+		return b.fail("Unknown type name: %s", name)
+	}
+
+	return b.add(&AliasSchema{AliasType: referenceName(fullName, b.namespace()), RefSchema: schema})
+}
+
+// Record starts a record schema with the given name, completed by EndRecord after its fields.
+func (b *SchemaBuilder) Record(name string) *SchemaBuilder {
+	return b.open(&RecordSchema{Name: name, Fields: make([]*SchemaField, 0), Properties: make(map[string]interface{})})
+}
+
+// Field starts a field of the innermost record with the given name, completed by its type.
+func (b *SchemaBuilder) Field(name string) *SchemaBuilder {
+	if b.err != nil || b.pending("field "+name) {
+		return b
+	}
+	frame := b.top()
+	record, ok := frame.schema.(*RecordSchema)
+	if !ok {
+		return b.unexpected("field " + name)
+	}
+
+	b.defineRecord(frame)
+	field := &SchemaField{Name: name, Properties: make(map[string]interface{})}
+	record.Fields = append(record.Fields, field)
+	b.stack = append(b.stack, &builderFrame{field: field})
+	return b
+}
+
+// EndRecord completes the innermost record.
+func (b *SchemaBuilder) EndRecord() *SchemaBuilder {
+	if b.err != nil || b.pending("the end of a record") {
+		return b
+	}
+	frame := b.top()
+	if _, ok := frame.schema.(*RecordSchema); !ok {
+		return b.unexpected("the end of a record")
+	}
+
+	b.defineRecord(frame)
+	return b.complete()
+}
+
+// Enum starts an enum schema with the given name, completed by Symbols.
+func (b *SchemaBuilder) Enum(name string) *SchemaBuilder {
+	return b.open(&EnumSchema{Name: name, Properties: make(map[string]interface{})})
+}
+
+// Symbols completes the innermost enum with the given symbols.
+func (b *SchemaBuilder) Symbols(symbols ...string) *SchemaBuilder {
+	if b.err != nil || b.pending("enum symbols") {
+		return b
+	}
+	enum, ok := b.top().schema.(*EnumSchema)
+	if !ok {
+		return b.unexpected("enum symbols")
+	}
+
+	enum.Symbols = symbols
+	if enum.Default != "" && !containsSymbol(symbols, enum.Default) {
+		return b.fail("Invalid default symbol of enum %s: %v", enum.Name, enum.Default)
+	}
+	b.define(enum.Name, enum.Namespace, enum)
+	return b.complete()
+}
+
+// Fixed starts a fixed schema with the given name, completed by Size.
+func (b *SchemaBuilder) Fixed(name string) *SchemaBuilder {
+	return b.open(&FixedSchema{Name: name, Properties: make(map[string]interface{})})
+}
+
+// Size completes the innermost fixed with the given size.
+func (b *SchemaBuilder) Size(size int) *SchemaBuilder {
+	if b.err != nil {
+		return b
+	}
+	fixed, ok := b.top().schema.(*FixedSchema)
+	if !ok {
+		return b.unexpected("a fixed size")
+	}
+	if b.optional {
+		return b.fail("Expected a schema after Optional, not a fixed size")
+	}
+
+	fixed.Size = size
+	fixed.LogicalType = b.logical(typeFixed, fixed)
+	b.define(fixed.Name, fixed.Namespace, fixed)
+	return b.complete()
+}
+
+// Array starts an array schema, completed by the schema of its items.
+func (b *SchemaBuilder) Array() *SchemaBuilder {
+	return b.open(&ArraySchema{Properties: make(map[string]interface{})})
+}
+
+// Map starts a map schema, completed by the schema of its values.
+func (b *SchemaBuilder) Map() *SchemaBuilder {
+	return b.open(&MapSchema{Properties: make(map[string]interface{})})
+}
+
+// Union starts a union schema, completed by EndUnion after its branches.
+func (b *SchemaBuilder) Union() *SchemaBuilder {
+	return b.open(&UnionSchema{Types: make([]Schema, 0)})
+}
+
+// EndUnion completes the innermost union.
+func (b *SchemaBuilder) EndUnion() *SchemaBuilder {
+	if b.err != nil || b.pending("the end of a union") {
+		return b
+	}
+	if _, ok := b.top().schema.(*UnionSchema); !ok {
+		return b.unexpected("the end of a union")
+	}
+
+	return b.complete()
+}
+
+// Optional makes the next schema a union of null and that schema. Null is the first branch, so the default value of
+// an optional field is null, unless the field has another default value, which then belongs to the first branch.
+func (b *SchemaBuilder) Optional() *SchemaBuilder {
+	b.optional = true
+	return b
+}
+
+// LogicalType annotates the next int, long, bytes, string or fixed schema with the given logical type, e.g.
+// LogicalType(TemporalType(DateLogicalType)).Int() or LogicalType(&DecimalType{Precision: 9, Scale: 2}).Bytes().
+func (b *SchemaBuilder) LogicalType(logicalType LogicalType) *SchemaBuilder {
+	b.logicalType = logicalType
+	return b
+}
+
+// Namespace sets the namespace of the innermost record, enum or fixed. The namespace of a record must be set before
+// its fields.
+func (b *SchemaBuilder) Namespace(namespace string) *SchemaBuilder {
+	if b.err != nil {
+		return b
+	}
+
+	frame := b.top()
+	switch s := frame.schema.(type) {
+	case *RecordSchema:
+		if frame.defined {
+			return b.fail("Cannot set namespace of record %s after its fields", s.Name)
+		}
+		s.Namespace = namespace
+	case *EnumSchema:
+		s.Namespace = namespace
+	case *FixedSchema:
+		s.Namespace = namespace
+	default:
+		return b.cannotSet("namespace")
+	}
+	return b
+}
+
+// Doc sets the documentation of the innermost record, enum or field.
+func (b *SchemaBuilder) Doc(doc string) *SchemaBuilder {
+	if b.err != nil {
+		return b
+	}
+
+	frame := b.top()
+	switch s := frame.schema.(type) {
+	case *RecordSchema:
+		s.Doc = doc
+	case *EnumSchema:
+		s.Doc = doc
+	case nil:
+		if frame.field == nil {
+			return b.cannotSet("doc")
+		}
+		frame.field.Doc = doc
+	default:
+		return b.cannotSet("doc")
+	}
+	return b
+}
+
+// Aliases sets the aliases of the innermost record, enum, fixed or field.
+func (b *SchemaBuilder) Aliases(aliases ...string) *SchemaBuilder {
+	if b.err != nil {
+		return b
+	}
+
+	frame := b.top()
+	switch s := frame.schema.(type) {
+	case *RecordSchema:
+		s.Aliases = aliases
+	case *EnumSchema:
+		s.Aliases = aliases
+	case *FixedSchema:
+		s.Aliases = aliases
+	case nil:
+		if frame.field == nil {
+			return b.cannotSet("aliases")
+		}
+		frame.field.Aliases = aliases
+	default:
+		return b.cannotSet("aliases")
+	}
+	return b
+}
+
+// Default sets the default value of the innermost field or the default symbol of the innermost enum. Default values
+// are given as parsed from JSON, e.g. map[string]interface{} for records, while Go integers are allowed as numbers.
+func (b *SchemaBuilder) Default(value interface{}) *SchemaBuilder {
+	if b.err != nil {
+		return b
+	}
+
+	frame := b.top()
+	switch s := frame.schema.(type) {
+	case *EnumSchema:
+		symbol, ok := value.(string)
+		if !ok {
+			return b.fail("Invalid default symbol of enum %s: %v", s.Name, value)
+		}
+		s.Default = symbol
+	case nil:
+		if frame.field == nil {
+			return b.cannotSet("default")
+		}
+		frame.field.Default = value
+		frame.field.HasDefault = true
+	default:
+		return b.cannotSet("default")
+	}
+	return b
+}
+
+// Order sets the sort order of the innermost field.
+func (b *SchemaBuilder) Order(order FieldOrder) *SchemaBuilder {
+	if b.err != nil {
+		return b
+	}
+
+	field := b.top().field
+	if field == nil {
+		return b.cannotSet("order")
+	}
+	switch order {
+	case AscendingOrder, DescendingOrder, IgnoreOrder:
+		field.Order = order
+	default:
+		return b.fail("Invalid order of field %s: %v", field.Name, order)
+	}
+	return b
+}
+
+// Prop sets a custom non-reserved property of the innermost record, enum, fixed, array, map or field.
+func (b *SchemaBuilder) Prop(key string, value interface{}) *SchemaBuilder {
+	if b.err != nil {
+		return b
+	}
+	if isReserved(key) {
+		return b.fail("Cannot set reserved property %s", key)
+	}
+
+	frame := b.top()
+	var properties map[string]interface{}
+	switch s := frame.schema.(type) {
+	case *RecordSchema:
+		properties = s.Properties
+	case *EnumSchema:
+		properties = s.Properties
+	case *FixedSchema:
+		properties = s.Properties
+	case *ArraySchema:
+		properties = s.Properties
+	case *MapSchema:
+		properties = s.Properties
+	case nil:
+		if frame.field == nil {
+			return b.cannotSet("property " + key)
+		}
+		properties = frame.field.Properties
+	default:
+		return b.cannotSet("property " + key)
+	}
+	properties[key] = value
+	return b
+}
+
+// open starts the given complex schema.
+func (b *SchemaBuilder) open(schema Schema) *SchemaBuilder {
+	if b.err != nil {
+		return b
+	}
+	if b.logicalType != nil && schema.Type() != Fixed {
+		return b.fail("Logical type %s is not valid for %s", b.logicalType.Name(), schema.GetName())
+	}
+	if !b.acceptsSchema() {
+		return b.unexpected(describeSchema(schema))
+	}
+
+	b.stack = append(b.stack, &builderFrame{schema: schema, optional: b.optional})
+	b.optional = false
+	return b
+}
+
+// complete completes the innermost schema and adds it to the schema or field it is built in.
+func (b *SchemaBuilder) complete() *SchemaBuilder {
+	frame := b.top()
+	b.stack = b.stack[:len(b.stack)-1]
+	b.optional = frame.optional
+	return b.add(frame.schema)
+}
+
+// add adds the given completed schema to the innermost schema or field.
+func (b *SchemaBuilder) add(schema Schema) *SchemaBuilder {
+	if b.err != nil {
+		return b
+	}
+	if b.logicalType != nil {
+		return b.fail("Logical type %s is not valid for %s", b.logicalType.Name(), schema.GetName())
+	}
+	if !b.acceptsSchema() {
+		return b.unexpected(describeSchema(schema))
+	}
+
+	frame := b.top()
+	if b.optional {
+		b.optional = false
+		if frame.field != nil && frame.field.Default != nil {
+			schema = &UnionSchema{Types: []Schema{schema, new(NullSchema)}}
+		} else {
+			schema = &UnionSchema{Types: []Schema{new(NullSchema), schema}}
+			if frame.field != nil {
+				frame.field.HasDefault = true
+			}
+		}
+	}
+
+	switch s := frame.schema.(type) {
+	case *ArraySchema:
+		s.Items = schema
+		return b.complete()
+	case *MapSchema:
+		s.Values = schema
+		return b.complete()
+	case *UnionSchema:
+		s.Types = append(s.Types, schema)
+		return b
+	}
+	if frame.field != nil {
+		frame.field.Type = schema
+		frame.field.Default = builderDefault(schema, frame.field.Default)
+		b.stack = b.stack[:len(b.stack)-1]
+		return b
+	}
+
+	b.schema = schema
+	return b
+}
+
+// acceptsSchema tells whether the innermost schema or field is completed by a schema, or else no schema is complete.
+func (b *SchemaBuilder) acceptsSchema() bool {
+	frame := b.top()
+	switch frame.schema.(type) {
+	case *ArraySchema, *MapSchema, *UnionSchema:
+		return true
+	case nil:
+		return frame.field != nil || b.schema == nil
+	}
+
+	return false
+}
+
+// logical returns the pending logical type if it is valid for the given underlying schema.
+func (b *SchemaBuilder) logical(typeName string, schema Schema) LogicalType {
+	if b.err != nil || b.logicalType == nil {
+		return nil
+	}
+
+	logicalType := parseLogicalType(logicalTypeJSON(typeName, b.logicalType), schema)
+	if logicalType == nil {
+		b.fail("Logical type %s is not valid for %s", b.logicalType.Name(), schema.GetName())
+	}
+	b.logicalType = nil
+	return logicalType
+}
+
+// defineRecord registers the name of the record being built in the given frame, once its namespace is final.
+func (b *SchemaBuilder) defineRecord(frame *builderFrame) {
+	if !frame.defined {
+		record := frame.schema.(*RecordSchema)
+		frame.namespace = b.define(record.Name, record.Namespace, newRecursiveSchema(record))
+		frame.defined = true
+	}
+}
+
+// define registers a named schema by its full name and returns the namespace of the schemas it encloses.
+func (b *SchemaBuilder) define(name string, namespace string, schema Schema) string {
+	fullName, namespace := canonicalName(name, namespace, b.namespace())
+	if _, exists := b.registry[fullName]; exists {
+		b.fail("Duplicate definition of %s", fullName)
+	}
+	b.registry[fullName] = schema
+	return namespace
+}
+
+// namespace returns the namespace of the innermost record with a registered name.
+func (b *SchemaBuilder) namespace() string {
+	for i := len(b.stack) - 1; i >= 0; i-- {
+		if b.stack[i].defined {
+			return b.stack[i].namespace
+		}
+	}
+
+	return ""
+}
+
+func (b *SchemaBuilder) top() *builderFrame {
+	if len(b.stack) == 0 {
+		return &builderFrame{}
+	}
+	return b.stack[len(b.stack)-1]
+}
+
+// pending fails if Optional or LogicalType is not followed by a schema but the given part.
+func (b *SchemaBuilder) pending(part string) bool {
+	if b.optional || b.logicalType != nil {
+		b.fail("Expected a schema after Optional or LogicalType, not %s", part)
+		return true
+	}
+	return false
+}
+
+// unexpected fails with what the innermost schema or field is completed by instead of the given part.
+func (b *SchemaBuilder) unexpected(part string) *SchemaBuilder {
+	frame := b.top()
+	var expected string
+	switch s := frame.schema.(type) {
+	case *RecordSchema:
+		expected = "a field or the end of record " + s.Name
+	case *EnumSchema:
+		expected = "the symbols of enum " + s.Name
+	case *FixedSchema:
+		expected = "the size of fixed " + s.Name
+	case *ArraySchema:
+		expected = "the items of an array"
+	case *MapSchema:
+		expected = "the values of a map"
+	case *UnionSchema:
+		expected = "a branch or the end of a union"
+	case nil:
+		if frame.field != nil {
+			expected = "the type of field " + frame.field.Name
+		} else if b.schema == nil {
+			expected = "a schema"
+		} else {
+			expected = "nothing after the complete schema"
+		}
+	}
+
+	return b.fail("Expected %s, not %s", expected, part)
+}
+
+// cannotSet fails for an attribute that the innermost schema or field does not have.
+func (b *SchemaBuilder) cannotSet(attribute string) *SchemaBuilder {
+	frame := b.top()
+	if frame.field != nil {
+		return b.fail("Cannot set %s of field %s", attribute, frame.field.Name)
+	}
+	if frame.schema == nil {
+		return b.fail("Cannot set %s outside of a schema", attribute)
+	}
+	return b.fail("Cannot set %s of %s", attribute, describeSchema(frame.schema))
+}
+
+// fail keeps the first error to be returned by Build.
+func (b *SchemaBuilder) fail(format string, args ...interface{}) *SchemaBuilder {
+	if b.err == nil {
+		b.err = fmt.Errorf(format, args...)
+	}
+	return b
+}
+
+func describeSchema(schema Schema) string {
+	switch s := schema.(type) {
+	case *RecordSchema:
+		return typeRecord + " " + s.Name
+	case *EnumSchema:
+		return typeEnum + " " + s.Name
+	case *FixedSchema:
+		return typeFixed + " " + s.Name
+	case *AliasSchema:
+		return "reference to " + s.AliasType
+	}
+
+	return schema.GetName()
+}
+
+// builderDefault converts default values that are Go numbers to the types ParseSchema uses for the given schema.
+func builderDefault(schema Schema, value interface{}) interface{} {
+	switch schema.Type() {
+	case Int:
+		if number, ok := jsonInt(value); ok {
+			return int32(number)
+		}
+	case Long:
+		switch number := value.(type) {
+		case int:
+			return int64(number)
+		case int32:
+			return int64(number)
+		case float64:
+			if number == math.Trunc(number) {
+				return int64(number)
+			}
+		}
+	case Float:
+		if number, ok := jsonNumber(value); ok {
+			return float32(number)
+		}
+	case Double:
+		if number, ok := jsonNumber(value); ok {
+			return number
+		}
+	}
+
+	return value
+}
@@ -0,0 +1,89 @@
+package avro
+
+import "testing"
+
+func TestSchemaBuilder(t *testing.T) {
+	schema, err := NewSchemaBuilder().
+		Record("User").Namespace("example.avro").Doc("A user").Prop("owner", "team").
+		Field("id").Long().
+		Field("email").Optional().String().
+		Field("age").Default(18).Int().
+		Field("score").Default(1).Optional().Double().
+		Field("tags").Array().String().
+		Field("role").Enum("Role").Default("USER").Symbols("USER", "ADMIN").
+		Field("previous").Order(IgnoreOrder).Aliases("old").Ref("Role").
+		Field("hash").Fixed("Hash").Namespace("example.crypto").Size(16).
+		Field("created").LogicalType(TemporalType(TimestampMillisLogicalType)).Long().
+		Field("balance").LogicalType(&DecimalType{Precision: 9, Scale: 2}).Bytes().
+		Field("attributes").Map().Union().Int().String().EndUnion().
+		Field("manager").Optional().Ref("User").
+		EndRecord().
+		Build()
+	assert(t, err, nil)
+
+	parsed := MustParseSchema(`{"type": "record", "name": "User", "namespace": "example.avro", "doc": "A user", "owner": "team", "fields": [
+		{"name": "id", "type": "long"},
+		{"name": "email", "type": ["null", "string"], "default": null},
+		{"name": "age", "type": "int", "default": 18},
+		{"name": "score", "type": ["double", "null"], "default": 1},
+		{"name": "tags", "type": {"type": "array", "items": "string"}},
+		{"name": "role", "type": {"type": "enum", "name": "Role", "symbols": ["USER", "ADMIN"], "default": "USER"}},
+		{"name": "previous", "type": "Role", "order": "ignore", "aliases": ["old"]},
+		{"name": "hash", "type": {"type": "fixed", "name": "Hash", "namespace": "example.crypto", "size": 16}},
+		{"name": "created", "type": {"type": "long", "logicalType": "timestamp-millis"}},
+		{"name": "balance", "type": {"type": "bytes", "logicalType": "decimal", "precision": 9, "scale": 2}},
+		{"name": "attributes", "type": {"type": "map", "values": ["int", "string"]}},
+		{"name": "manager", "type": ["null", "User"], "default": null}
+	]}`)
+	assert(t, schema.String(), parsed.String())
+	assert(t, CanonicalForm(schema), CanonicalForm(parsed))
+
+	record := schema.(*RecordSchema)
+	assert(t, record.Fields[2].Default, int32(18))
+	assert(t, record.Fields[1].HasDefault, true)
+	assert(t, record.Fields[0].HasDefault, false)
+	assert(t, record.Fields[6].Type.(*AliasSchema).RefSchema, record.Fields[5].Type)
+	assert(t, record.Fields[11].Type.(*UnionSchema).Types[1].(*AliasSchema).RefSchema, &RecursiveSchema{Actual: record})
+	prop, _ := record.Prop("owner")
+	assert(t, prop, "team")
+
+	primitive, err := NewSchemaBuilder().LogicalType(&UUIDType{}).String().Build()
+	assert(t, err, nil)
+	assert(t, primitive, Schema(&StringSchema{LogicalType: &UUIDType{}}))
+}
+
+func TestSchemaBuilderErrors(t *testing.T) {
+	_, err := NewSchemaBuilder().Record("A").Field("a").Int().Field("b").Build()
+	assert(t, err.Error(), "Expected the type of field b, not the end of the schema")
+
+	_, err = NewSchemaBuilder().Record("A").Int().EndRecord().Build()
+	assert(t, err.Error(), "Expected a field or the end of record A, not int")
+
+	_, err = NewSchemaBuilder().Array().Doc("items").String().Build()
+	assert(t, err.Error(), "Cannot set doc of array")
+
+	_, err = NewSchemaBuilder().Record("A").Field("a").Ref("B").EndRecord().Build()
+	assert(t, err.Error(), "Unknown type name: B")
+
+	_, err = NewSchemaBuilder().Record("A").Field("a").LogicalType(TemporalType(DateLogicalType)).Long().EndRecord().Build()
+	assert(t, err.Error(), "Logical type date is not valid for long")
+
+	_, err = NewSchemaBuilder().LogicalType(&DecimalType{Precision: 4, Scale: 5}).Bytes().Build()
+	assert(t, err.Error(), "Logical type decimal is not valid for bytes")
+
+	_, err = NewSchemaBuilder().Enum("E").Default("C").Symbols("A", "B").Build()
+	assert(t, err.Error(), "Invalid default symbol of enum E: C")
+
+	_, err = NewSchemaBuilder().Record("A").Field("a").Fixed("A").Size(1).EndRecord().Build()
+	assert(t, err.Error(), "Duplicate definition of A")
+
+	_, err = NewSchemaBuilder().Int().String().Build()
+	assert(t, err.Error(), "Expected nothing after the complete schema, not string")
+
+	_, err = NewSchemaBuilder().Record("1A").Field("a").Default("one").Int().EndRecord().Build()
+	violations, ok := err.(SchemaViolations)
+	assert(t, ok, true)
+	assert(t, len(violations), 2)
+	assert(t, violations[0].Path, "$.name")
+	assert(t, violations[1].Path, "$.fields[0].default")
+}
@@ -0,0 +1,160 @@
+package avro
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/json"
+	"strconv"
+	"strings"
+)
+
+// Parsing Canonical Form of schemas and fingerprints based on it.
+// Spec: https://avro.apache.org/docs/current/spec.html#Parsing+Canonical+Form+for+Schemas
+
+// CanonicalForm returns the Parsing Canonical Form of the given schema. Two schemas have the same canonical form if
+// and only if they encode data the same way, no matter their docs, aliases, defaults, logical types and other
+// attributes, namespaces given separately from names or the order of attributes.
+func CanonicalForm(schema Schema) string {
+	buf := &bytes.Buffer{}
+	writeCanonicalForm(buf, schema, "", make(map[string]bool))
+	return buf.String()
+}
+
+func writeCanonicalForm(buf *bytes.Buffer, schema Schema, namespace string, defined map[string]bool) {
+	if alias, ok := schema.(*AliasSchema); ok && alias.AliasType != "" {
+		// a type without a namespace of its own inherits the one it is defined in, which is not the one it may be
+		// referred to from, but the referring name resolves to its full name
+		_, namespace = canonicalName(alias.AliasType, "", namespace)
+		writeCanonicalForm(buf, alias.RefSchema, namespace, defined)
+		return
+	}
+
+	switch s := actualSchema(schema).(type) {
+	case *RecordSchema:
+		name, namespace := canonicalName(s.Name, s.Namespace, namespace)
+		if writeNamedCanonicalForm(buf, name, typeRecord, defined) {
+			return
+		}
+		buf.WriteString(`,"fields":[`)
+		for i, field := range s.Fields {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			buf.WriteString(`{"name":`)
+			writeCanonicalString(buf, field.Name)
+			buf.WriteString(`,"type":`)
+			writeCanonicalForm(buf, field.Type, namespace, defined)
+			buf.WriteByte('}')
+		}
+		buf.WriteString("]}")
+	case *EnumSchema:
+		name, _ := canonicalName(s.Name, s.Namespace, namespace)
+		if writeNamedCanonicalForm(buf, name, typeEnum, defined) {
+			return
+		}
+		buf.WriteString(`,"symbols":[`)
+		for i, symbol := range s.Symbols {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			writeCanonicalString(buf, symbol)
+		}
+		buf.WriteString("]}")
+	case *FixedSchema:
+		name, _ := canonicalName(s.Name, s.Namespace, namespace)
+		if writeNamedCanonicalForm(buf, name, typeFixed, defined) {
+			return
+		}
+		buf.WriteString(`,"size":`)
+		buf.WriteString(strconv.Itoa(s.Size))
+		buf.WriteByte('}')
+	case *ArraySchema:
+		buf.WriteString(`{"type":"array","items":`)
+		writeCanonicalForm(buf, s.Items, namespace, defined)
+		buf.WriteByte('}')
+	case *MapSchema:
+		buf.WriteString(`{"type":"map","values":`)
+		writeCanonicalForm(buf, s.Values, namespace, defined)
+		buf.WriteByte('}')
+	case *UnionSchema:
+		buf.WriteByte('[')
+		for i, t := range s.Types {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			writeCanonicalForm(buf, t, namespace, defined)
+		}
+		buf.WriteByte(']')
+	default:
+		writeCanonicalString(buf, s.GetName())
+	}
+}
+
+// writeNamedCanonicalForm writes the start of a named schema, or only its full name if it is already defined, in
+// which case it returns true.
+func writeNamedCanonicalForm(buf *bytes.Buffer, name string, typeName string, defined map[string]bool) bool {
+	if defined[name] {
+		writeCanonicalString(buf, name)
+		return true
+	}
+	defined[name] = true
+
+	buf.WriteString(`{"name":`)
+	writeCanonicalString(buf, name)
+	buf.WriteString(`,"type":`)
+	writeCanonicalString(buf, typeName)
+	return false
+}
+
+// canonicalName returns the full name of a named schema and the namespace of the schemas it encloses.
+func canonicalName(name string, namespace string, enclosing string) (string, string) {
+	if namespace == "" {
+		namespace = enclosing
+	}
+	fullName := getFullName(name, namespace)
+	if i := strings.LastIndex(fullName, "."); i >= 0 {
+		return fullName, fullName[:i]
+	}
+	return fullName, ""
+}
+
+func writeCanonicalString(buf *bytes.Buffer, s string) {
+	bytes, err := json.Marshal(s)
+	if err != nil {
+		panic(err)
+	}
+	buf.Write(bytes)
+}
+
+// Fingerprint64 returns the CRC-64-AVRO Rabin fingerprint of the canonical form of the given schema.
+func Fingerprint64(schema Schema) uint64 {
+	fingerprint := uint64(rabinEmpty)
+	for _, b := range []byte(CanonicalForm(schema)) {
+		fingerprint = (fingerprint >> 8) ^ rabinTable[byte(fingerprint)^b]
+	}
+	return fingerprint
+}
+
+// FingerprintMD5 returns the MD5 fingerprint of the canonical form of the given schema.
+func FingerprintMD5(schema Schema) [md5.Size]byte {
+	return md5.Sum([]byte(CanonicalForm(schema)))
+}
+
+// FingerprintSHA256 returns the SHA-256 fingerprint of the canonical form of the given schema.
+func FingerprintSHA256(schema Schema) [sha256.Size]byte {
+	return sha256.Sum256([]byte(CanonicalForm(schema)))
+}
+
+const rabinEmpty = 0xc15d213aa4d7a795
+
+var rabinTable = func() (table [256]uint64) {
+	for i := range table {
+		fingerprint := uint64(i)
+		for j := 0; j < 8; j++ {
+			fingerprint = (fingerprint >> 1) ^ (rabinEmpty & -(fingerprint & 1))
+		}
+		table[i] = fingerprint
+	}
+	return table
+}()
@@ -0,0 +1,66 @@
+package avro
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+func TestCanonicalForm(t *testing.T) {
+	assert(t, CanonicalForm(MustParseSchema(`{"type": "int", "logicalType": "date"}`)), `"int"`)
+	assert(t, CanonicalForm(MustParseSchema(`["null", {"type": "array", "items": "string"}, {"type": "map", "values": "long"}]`)),
+		`["null",{"type":"array","items":"string"},{"type":"map","values":"long"}]`)
+
+	schema := MustParseSchema(`{"namespace": "com.example", "type": "record", "name": "Node", "doc": "A node",
+		"aliases": ["Vertex"], "custom": true, "fields": [
+			{"name": "id", "type": {"type": "fixed", "size": 16, "name": "Id", "namespace": "com.example.ids"}, "doc": "ID"},
+			{"name": "color", "type": {"type": "enum", "name": "Color", "symbols": ["RED", "GREEN"]}, "default": "RED"},
+			{"name": "parent", "type": ["null", "Node"], "default": null},
+			{"name": "same", "type": "Color"},
+			{"name": "child", "type": {"type": "record", "name": "other.Child", "fields": [
+				{"name": "shade", "type": {"type": "enum", "name": "Shade", "symbols": ["DARK"]}}
+			]}}
+		]}`)
+	assert(t, CanonicalForm(schema), `{"name":"com.example.Node","type":"record","fields":[`+
+		`{"name":"id","type":{"name":"com.example.ids.Id","type":"fixed","size":16}},`+
+		`{"name":"color","type":{"name":"com.example.Color","type":"enum","symbols":["RED","GREEN"]}},`+
+		`{"name":"parent","type":["null","com.example.Node"]},`+
+		`{"name":"same","type":"com.example.Color"},`+
+		`{"name":"child","type":{"name":"other.Child","type":"record","fields":[`+
+		`{"name":"shade","type":{"name":"other.Shade","type":"enum","symbols":["DARK"]}}]}}]}`)
+
+	// the canonical form parses to the same canonical form
+	assert(t, CanonicalForm(MustParseSchema(CanonicalForm(schema))), CanonicalForm(schema))
+
+	// types are named where they are defined, not where they are referred to from
+	schema = MustParseSchema(`{"type": "record", "name": "com.example.R", "fields": [
+		{"name": "color", "type": {"type": "enum", "name": "Color", "symbols": ["RED"]}},
+		{"name": "o", "type": {"type": "record", "name": "other.O", "fields": [
+			{"name": "color", "type": ["null", "com.example.Color"]}
+		]}}
+	]}`)
+	assert(t, CanonicalForm(schema), `{"name":"com.example.R","type":"record","fields":[`+
+		`{"name":"color","type":{"name":"com.example.Color","type":"enum","symbols":["RED"]}},`+
+		`{"name":"o","type":{"name":"other.O","type":"record","fields":[`+
+		`{"name":"color","type":["null","com.example.Color"]}]}}]}`)
+	assert(t, CanonicalForm(MustParseSchema(CanonicalForm(schema))), CanonicalForm(schema))
+}
+
+func TestFingerprints(t *testing.T) {
+	for _, c := range []struct {
+		schema      string
+		fingerprint int64
+	}{
+		{`"null"`, 7195948357588979594},
+		{`"boolean"`, -6970731678124411036},
+		{`"int"`, 8247732601305521295},
+		{`"long"`, -3434872931120570953},
+		{`"string"`, -8142146995180207161},
+	} {
+		assert(t, int64(Fingerprint64(MustParseSchema(c.schema))), c.fingerprint)
+	}
+
+	md5 := FingerprintMD5(MustParseSchema(`"int"`))
+	assert(t, hex.EncodeToString(md5[:]), "ef524ea1b91e73173d938ade36c1db32")
+	sha := FingerprintSHA256(MustParseSchema(`"int"`))
+	assert(t, hex.EncodeToString(sha[:]), "3f2b87a9fe7cc9b13835598c3981cd45e3e355309e5090aa0933d7becb6fba45")
+}
@@ -17,15 +17,19 @@ package avro
 
 import (
 	"io/ioutil"
+	"path/filepath"
 	"strings"
 )
 
-const schemaExtension = ".avsc"
+const (
+	schemaExtension = ".avsc"
+	idlExtension    = ".avdl"
+)
 
 // LoadSchemas loads and parses a schema file or directory.
 // Directory names MUST end with "/"
 func LoadSchemas(path string) map[string]Schema {
-	files := getFiles(path, make([]string, 0))
+	files := getFiles(path, schemaExtension, make([]string, 0))
 
 	schemas := make(map[string]Schema)
 
@@ -40,7 +44,37 @@ func LoadSchemas(path string) map[string]Schema {
 	return schemas
 }
 
-func getFiles(path string, files []string) []string {
+// LoadIDLSchemas loads and parses an Avro IDL file or directory, including the files they import.
+// Returns all named schemas by their full names, or an empty map if any file cannot be parsed like LoadSchemas, use
+// ParseIDLFile to get the error. Directory names MUST end with "/"
+func LoadIDLSchemas(path string) map[string]Schema {
+	files := []string{path}
+	if !strings.HasSuffix(path, idlExtension) {
+		files = getFiles(path, idlExtension, make([]string, 0))
+	}
+
+	registry := make(map[string]Schema)
+	imported := make(map[string]bool)
+	for _, file := range files {
+		if imported[filepath.Clean(file)] {
+			continue
+		}
+		if _, err := parseIDLFile(file, registry, imported); err != nil {
+			return make(map[string]Schema)
+		}
+	}
+
+	schemas := make(map[string]Schema, len(registry))
+	for name, schema := range registry {
+		if recursive, ok := schema.(*RecursiveSchema); ok {
+			schema = recursive.Actual
+		}
+		schemas[name] = schema
+	}
+	return schemas
+}
+
+func getFiles(path string, extension string, files []string) []string {
 	list, err := ioutil.ReadDir(path)
 	if err != nil {
 		return nil
@@ -48,12 +82,12 @@ func getFiles(path string, files []string) []string {
 
 	for _, file := range list {
 		if file.IsDir() {
-			files = getFiles(path+file.Name()+"/", files)
+			files = getFiles(path+file.Name()+"/", extension, files)
 			if files == nil {
 				return nil
 			}
 		} else if file.Mode().IsRegular() {
-			if strings.HasSuffix(file.Name(), schemaExtension) {
+			if strings.HasSuffix(file.Name(), extension) {
 				files = addFile(path+file.Name(), files)
 			}
 		}
@@ -77,10 +77,14 @@ func (job *prepareJob) prepareRecordSchema(input *RecordSchema) *preparedRecordS
 	output.Fields = nil
 	for _, field := range input.Fields {
 		output.Fields = append(output.Fields, &SchemaField{
-			Name:    field.Name,
-			Doc:     field.Doc,
-			Default: field.Default,
-			Type:    job.prepare(field.Type),
+			Name:       field.Name,
+			Aliases:    field.Aliases,
+			Doc:        field.Doc,
+			Default:    field.Default,
+			HasDefault: field.HasDefault,
+			Type:       job.prepare(field.Type),
+			Order:      field.Order,
+			Properties: field.Properties,
 		})
 	}
 	return output
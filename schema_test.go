@@ -297,6 +297,22 @@ func TestSchemaRegistryMap(t *testing.T) {
 	assert(t, err, nil)
 	assert(t, s4.Type(), Record)
 	assert(t, len(registry), 4)
+
+	// enums and fixeds are registered in their own namespace and referred to by full name from other namespaces
+	rawSchema5 := `{"type": "record", "name": "TestRecord5", "namespace": "com.github.elodina", "fields": [
+		{"name": "kind", "type": {"type": "enum", "name": "Kind", "namespace": "com.github.other", "symbols": ["A"]}},
+		{"name": "hash", "type": {"type": "fixed", "name": "Hash", "namespace": "com.github.other", "size": 2}},
+		{"name": "kinds", "type": {"type": "array", "items": "com.github.other.Kind"}},
+		{"name": "hashes", "type": {"type": "map", "values": "com.github.other.Hash"}}
+	]}`
+	s5, err := ParseSchemaWithRegistry(rawSchema5, registry)
+	assert(t, err, nil)
+	assert(t, registry["com.github.other.Kind"], s5.(*RecordSchema).Fields[0].Type)
+	assert(t, registry["com.github.other.Hash"], s5.(*RecordSchema).Fields[1].Type)
+	reparsed, err := ParseSchema(s5.String())
+	assert(t, err, nil)
+	assert(t, reparsed.String(), s5.String())
+	assert(t, reparsed.(*RecordSchema).Fields[2].Type.(*ArraySchema).Items.(*AliasSchema).AliasType, "com.github.other.Kind")
 }
 
 func TestRecordCustomProps(t *testing.T) {
@@ -315,6 +331,29 @@ func TestRecordCustomProps(t *testing.T) {
 	assert(t, value, "world")
 }
 
+func TestEnumDefaultAndFieldOrder(t *testing.T) {
+	s, err := ParseSchema(`{"type": "record", "name": "Ticket", "fields": [
+		{"name": "id", "type": "long", "order": "descending"},
+		{"name": "note", "type": "string", "order": "ignore"},
+		{"name": "state", "type": {"type": "enum", "name": "State", "symbols": ["OPEN", "CLOSED", "UNKNOWN"], "default": "UNKNOWN"}}
+	]}`)
+	assert(t, err, nil)
+	record := s.(*RecordSchema)
+	assert(t, record.Fields[0].Order, DescendingOrder)
+	assert(t, record.Fields[1].Order, IgnoreOrder)
+	assert(t, record.Fields[2].Order, FieldOrder(""))
+	assert(t, record.Fields[2].Type.(*EnumSchema).Default, "UNKNOWN")
+
+	parsed := MustParseSchema(s.String()).(*RecordSchema)
+	assert(t, parsed.Fields[0].Order, DescendingOrder)
+	assert(t, parsed.Fields[2].Type.(*EnumSchema).Default, "UNKNOWN")
+
+	_, err = ParseSchema(`{"type": "enum", "name": "State", "symbols": ["OPEN"], "default": "CLOSED"}`)
+	assert(t, err != nil, true)
+	_, err = ParseSchema(`{"type": "record", "name": "Ticket", "fields": [{"name": "id", "type": "long", "order": "random"}]}`)
+	assert(t, err != nil, true)
+}
+
 func TestLoadSchemas(t *testing.T) {
 	schemas := LoadSchemas("test/schemas/")
 	assert(t, len(schemas), 4)
@@ -0,0 +1,195 @@
+package avro
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Validation of schemas against the rules of the specification the parser does not enforce, like the one of Java.
+
+// SchemaViolation describes a part of a schema that does not conform to the specification.
+type SchemaViolation struct {
+	// Path is a JSON path to the violating part of the schema, e.g. "$.fields[2].type.items".
+	Path string
+
+	// Message describes the violation.
+	Message string
+}
+
+// String returns a description of this SchemaViolation.
+func (v SchemaViolation) String() string {
+	return fmt.Sprintf("%s: %s", v.Path, v.Message)
+}
+
+// SchemaViolations is the error returned by ParseSchemaStrict for a schema violating the specification.
+type SchemaViolations []SchemaViolation
+
+// Error lists all violations.
+func (v SchemaViolations) Error() string {
+	messages := make([]string, len(v))
+	for i, violation := range v {
+		messages[i] = violation.String()
+	}
+	return "Invalid schema: " + strings.Join(messages, "; ")
+}
+
+// ParseSchemaStrict is like ParseSchema, but also validates the schema with ValidateSchema and returns its violations
+// as SchemaViolations.
+func ParseSchemaStrict(rawSchema string) (Schema, error) {
+	schema, err := ParseSchema(rawSchema)
+	if err != nil {
+		return nil, err
+	}
+
+	if violations := ValidateSchema(schema); len(violations) > 0 {
+		return nil, violations
+	}
+	return schema, nil
+}
+
+// ValidateSchema returns all violations of the specification by the given schema, or nil if there are none:
+// invalid names, namespaces, aliases and enum symbols, duplicate field names and enum symbols, duplicate or nested
+// union branches, fixed schemas with negative sizes and default values not matching the field type.
+func ValidateSchema(schema Schema) SchemaViolations {
+	validator := &schemaValidator{defined: make(map[Schema]bool)}
+	validator.validate(schema, "$", "")
+	return validator.violations
+}
+
+var schemaNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+type schemaValidator struct {
+	violations SchemaViolations
+
+	// named schemas already validated, these are referenced by name anywhere else
+	defined map[Schema]bool
+}
+
+func (v *schemaValidator) add(path string, format string, args ...interface{}) {
+	v.violations = append(v.violations, SchemaViolation{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *schemaValidator) validate(schema Schema, path string, namespace string) {
+	switch s := schema.(type) {
+	case *AliasSchema, *RecursiveSchema:
+		// references to named schemas defined elsewhere
+		return
+	case *preparedRecordSchema:
+		schema = &s.RecordSchema
+	}
+	if v.defined[schema] {
+		return
+	}
+
+	switch s := schema.(type) {
+	case *RecordSchema:
+		v.defined[schema] = true
+		namespace = v.validateNamed(path, s.Name, s.Namespace, s.Aliases, namespace)
+		names := make(map[string]bool, len(s.Fields))
+		for i, field := range s.Fields {
+			fieldPath := fmt.Sprintf("%s.fields[%d]", path, i)
+			if !schemaNamePattern.MatchString(field.Name) {
+				v.add(fieldPath+".name", "Invalid field name %q", field.Name)
+			} else if names[field.Name] {
+				v.add(fieldPath+".name", "Duplicate field name %q", field.Name)
+			}
+			names[field.Name] = true
+			for j, alias := range field.Aliases {
+				if !schemaNamePattern.MatchString(alias) {
+					v.add(fmt.Sprintf("%s.aliases[%d]", fieldPath, j), "Invalid field alias %q", alias)
+				}
+			}
+
+			v.validate(field.Type, fieldPath+".type", namespace)
+			if field.HasDefault || field.Default != nil {
+				if err := encodeJSONValue(discardEncoder, field.Type, field.Default); err != nil {
+					v.add(fieldPath+".default", "Invalid default value: %v", err)
+				}
+			}
+		}
+	case *EnumSchema:
+		v.defined[schema] = true
+		v.validateNamed(path, s.Name, s.Namespace, s.Aliases, namespace)
+		symbols := make(map[string]bool, len(s.Symbols))
+		for i, symbol := range s.Symbols {
+			symbolPath := fmt.Sprintf("%s.symbols[%d]", path, i)
+			if !schemaNamePattern.MatchString(symbol) {
+				v.add(symbolPath, "Invalid enum symbol %q", symbol)
+			} else if symbols[symbol] {
+				v.add(symbolPath, "Duplicate enum symbol %q", symbol)
+			}
+			symbols[symbol] = true
+		}
+	case *FixedSchema:
+		v.defined[schema] = true
+		v.validateNamed(path, s.Name, s.Namespace, s.Aliases, namespace)
+		if s.Size < 0 {
+			v.add(path+".size", "Negative fixed size %d", s.Size)
+		}
+	case *ArraySchema:
+		v.validate(s.Items, path+".items", namespace)
+	case *MapSchema:
+		v.validate(s.Values, path+".values", namespace)
+	case *UnionSchema:
+		branches := make(map[string]bool, len(s.Types))
+		for i, branch := range s.Types {
+			branchPath := fmt.Sprintf("%s[%d]", path, i)
+			actual := actualSchema(branch)
+			key := actual.GetName()
+			if isNamed(actual) {
+				key, _ = canonicalName(actual.GetName(), namedSchemaNamespace(actual), namespace)
+			}
+
+			if actual.Type() == Union {
+				v.add(branchPath, "Unions may not immediately contain other unions")
+			} else if branches[key] {
+				v.add(branchPath, "Duplicate union branch %s", key)
+			}
+			branches[key] = true
+			v.validate(branch, branchPath, namespace)
+		}
+	}
+}
+
+// validateNamed validates the name, namespace and aliases of a named schema and returns the namespace of the schemas
+// it encloses.
+func (v *schemaValidator) validateNamed(path string, name string, namespace string, aliases []string, enclosing string) string {
+	if !validFullName(name) {
+		v.add(path+".name", "Invalid name %q", name)
+	}
+	if namespace != "" && !validFullName(namespace) {
+		v.add(path+".namespace", "Invalid namespace %q", namespace)
+	}
+	for i, alias := range aliases {
+		if !validFullName(alias) {
+			v.add(fmt.Sprintf("%s.aliases[%d]", path, i), "Invalid alias %q", alias)
+		}
+	}
+
+	_, namespace = canonicalName(name, namespace, enclosing)
+	return namespace
+}
+
+func validFullName(name string) bool {
+	for _, part := range strings.Split(name, ".") {
+		if !schemaNamePattern.MatchString(part) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func namedSchemaNamespace(schema Schema) string {
+	switch s := schema.(type) {
+	case *RecordSchema:
+		return s.Namespace
+	case *EnumSchema:
+		return s.Namespace
+	case *FixedSchema:
+		return s.Namespace
+	}
+
+	return ""
+}
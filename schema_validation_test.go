@@ -0,0 +1,53 @@
+package avro
+
+import "testing"
+
+func TestValidateSchema(t *testing.T) {
+	valid := `{"type": "record", "name": "Node", "namespace": "com.example", "fields": [
+		{"name": "id", "type": "long", "default": 0},
+		{"name": "kind", "type": {"type": "enum", "name": "Kind", "symbols": ["A", "B"]}, "default": "B"},
+		{"name": "next", "type": ["null", "Node"], "default": null},
+		{"name": "other", "type": {"type": "enum", "name": "other.Kind", "symbols": ["C"]}},
+		{"name": "tags", "type": {"type": "map", "values": ["string", "Kind", "other.Kind"]}}
+	]}`
+	schema, err := ParseSchemaStrict(valid)
+	assert(t, err, nil)
+	assert(t, ValidateSchema(schema), SchemaViolations(nil))
+
+	invalid := `{"type": "record", "name": "1Node", "namespace": "com..example", "fields": [
+		{"name": "id", "type": "long", "default": "zero"},
+		{"name": "id", "type": "int"},
+		{"name": "bad-name", "type": {"type": "fixed", "name": "Hash", "size": -1}},
+		{"name": "kind", "type": {"type": "enum", "name": "Kind", "symbols": ["A", "A", "0"]}},
+		{"name": "choice", "type": ["int", "string", "int", ["null"]]},
+		{"name": "items", "type": {"type": "array", "items": {"type": "record", "name": "Item", "fields": [
+			{"name": "count", "type": "int", "default": 1.5}
+		]}}},
+		{"name": "version", "type": "long", "default": null},
+		{"name": "size", "type": ["int", "null"], "default": null}
+	]}`
+	_, err = ParseSchemaStrict(invalid)
+	violations, ok := err.(SchemaViolations)
+	assert(t, ok, true)
+
+	var paths []string
+	for _, violation := range violations {
+		paths = append(paths, violation.Path)
+	}
+	assert(t, paths, []string{
+		"$.name",
+		"$.namespace",
+		"$.fields[0].default",
+		"$.fields[1].name",
+		"$.fields[2].name",
+		"$.fields[2].type.size",
+		"$.fields[3].type.symbols[1]",
+		"$.fields[3].type.symbols[2]",
+		"$.fields[4].type[2]",
+		"$.fields[4].type[3]",
+		"$.fields[5].type.items.fields[0].default",
+		"$.fields[6].default",
+		"$.fields[7].default",
+	})
+	assert(t, violations[3].String(), `$.fields[1].name: Duplicate field name "id"`)
+}